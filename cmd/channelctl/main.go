@@ -0,0 +1,130 @@
+package main
+
+import (
+	"alyo/internal/core/database"
+	"alyo/internal/core/models"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: channelctl <command> [flags]
+
+Commands:
+  list                      Show all channels and their configuration
+  add -id ID -name NAME     Add a channel (or update its configuration)
+      [-language id] [-priority N] [-include a,b] [-exclude c,d] [-disabled]
+  enable ID                 Enable a channel
+  disable ID                Disable a channel; its data stays in the catalog
+`
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL must be set")
+	}
+	store, err := database.NewDBStore(dbURL)
+	if err != nil {
+		log.Fatalf("Could not connect to the database: %v", err)
+	}
+
+	ctx := context.Background()
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "list":
+		err = listChannels(ctx, store)
+	case "add":
+		err = addChannel(ctx, store, args)
+	case "enable", "disable":
+		if len(args) != 1 {
+			log.Fatalf("Usage: channelctl %s ID", cmd)
+		}
+		err = store.SetChannelEnabled(ctx, args[0], cmd == "enable")
+		if err == nil {
+			log.Printf("Channel %s %sd", args[0], cmd)
+		}
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("ERROR: %v", err)
+	}
+}
+
+// listChannels mencetak semua channel dalam bentuk tabel.
+func listChannels(ctx context.Context, store database.Store) error {
+	channels, err := store.ListChannels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHANNEL ID\tNAME\tENABLED\tPRIORITY\tLANGUAGE\tINCLUDE\tEXCLUDE")
+	for _, ch := range channels {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%d\t%s\t%s\t%s\n", ch.ID, ch.Name, ch.Enabled, ch.Priority, deref(ch.DefaultLanguage), deref(ch.PlaylistInclude), deref(ch.PlaylistExclude))
+	}
+	return w.Flush()
+}
+
+// addChannel mendaftarkan channel dari flag command line.
+func addChannel(ctx context.Context, store database.Store, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	id := fs.String("id", "", "YouTube channel ID (UC...)")
+	name := fs.String("name", "", "display name")
+	language := fs.String("language", "", "default subtitle language when a playlist title has none (e.g. id, en)")
+	priority := fs.Int("priority", 0, "higher priority channels are synced first")
+	include := fs.String("include", "", "comma-separated keywords; only playlists containing one are synced")
+	exclude := fs.String("exclude", "", "comma-separated keywords; playlists containing one are skipped")
+	disabled := fs.Bool("disabled", false, "add the channel without syncing it yet")
+	fs.Parse(args)
+
+	if !strings.HasPrefix(*id, "UC") || *name == "" {
+		return fmt.Errorf("-id (a UC... channel ID) and -name are required")
+	}
+
+	channel := models.Channel{
+		ID:              *id,
+		Name:            *name,
+		URL:             "https://www.youtube.com/channel/" + *id,
+		Enabled:         !*disabled,
+		DefaultLanguage: optional(*language),
+		Priority:        *priority,
+		PlaylistInclude: optional(*include),
+		PlaylistExclude: optional(*exclude),
+	}
+	if err := store.AddChannel(ctx, channel); err != nil {
+		return fmt.Errorf("failed to add channel: %w", err)
+	}
+	log.Printf("Channel %s (%s) saved", channel.Name, channel.ID)
+	return nil
+}
+
+func optional(v string) *string {
+	if v = strings.TrimSpace(v); v == "" {
+		return nil
+	}
+	return &v
+}
+
+func deref(v *string) string {
+	if v == nil {
+		return "-"
+	}
+	return *v
+}
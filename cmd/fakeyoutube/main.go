@@ -0,0 +1,35 @@
+package main
+
+import (
+	"alyo/internal/youtube/youtubetest"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// fakeyoutube menjalankan server YouTube Data API palsu dari fixture, sehingga
+// worker bisa dijalankan offline dengan YOUTUBE_API_BASE_URL menunjuk ke server ini.
+func main() {
+	fixturesDir := flag.String("fixtures", "", "directory with channels.json, playlists.json, playlistItems.json and videos.json (default: built-in fixtures)")
+	flag.Parse()
+
+	var srv *youtubetest.Server
+	if *fixturesDir == "" {
+		srv = youtubetest.NewServer()
+	} else {
+		var err error
+		srv, err = youtubetest.NewServerFS(os.DirFS(*fixturesDir))
+		if err != nil {
+			log.Fatalf("Could not load fixtures: %v", err)
+		}
+	}
+	defer srv.Close()
+
+	log.Printf("Fake YouTube Data API listening, set YOUTUBE_API_BASE_URL=%s", srv.URL)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+}
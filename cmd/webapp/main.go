@@ -2,13 +2,22 @@ package main
 
 import (
 	"alyo/internal/core/database"
+	"alyo/internal/core/models"
+	"alyo/internal/youtube"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"html/template"
+	"io"
 	"log"
 	"math"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -18,8 +27,16 @@ import (
 )
 
 type Application struct {
-	Store     database.Store
-	Templates map[string]*template.Template
+	Store       database.Store
+	Templates   map[string]*template.Template
+	QuotaBudget int64
+
+	// AdminToken melindungi endpoint /api/v1/admin; kosong berarti endpoint admin dimatikan.
+	AdminToken string
+	// ShutdownTimeout adalah batas waktu menunggu request yang sedang berjalan saat server dimatikan.
+	ShutdownTimeout time.Duration
+	// Suggestions menyimpan hasil autocomplete pencarian di memori.
+	Suggestions *suggestCache
 }
 
 func main() {
@@ -38,24 +55,71 @@ func main() {
 		log.Fatalf("Could not connect to the database: %v", err)
 	}
 
-	app := &Application{Store: store}
+	// Budget default mengikuti worker: quota harian penuh untuk setiap API key.
+	keyCount := len(youtube.ParseAPIKeys(os.Getenv("YOUTUBE_API_KEYS"), os.Getenv("YOUTUBE_API_KEY")))
+	if keyCount == 0 {
+		keyCount = 1
+	}
+	quotaBudget := int64(youtube.DefaultDailyBudget * keyCount)
+	if v := os.Getenv("YOUTUBE_DAILY_QUOTA_BUDGET"); v != "" {
+		quotaBudget, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid YOUTUBE_DAILY_QUOTA_BUDGET: %v", err)
+		}
+	}
+
+	adminToken := os.Getenv("ADMIN_API_TOKEN")
+	if adminToken == "" {
+		log.Println("WARN: ADMIN_API_TOKEN is not set, admin endpoints are disabled")
+	}
+
+	shutdownTimeout := 15 * time.Second
+	if v := os.Getenv("WEBAPP_SHUTDOWN_TIMEOUT"); v != "" {
+		shutdownTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WEBAPP_SHUTDOWN_TIMEOUT: %v", err)
+		}
+	}
+
+	suggestCacheTTL := time.Minute
+	if v := os.Getenv("WEBAPP_SUGGEST_CACHE_TTL"); v != "" {
+		suggestCacheTTL, err = time.ParseDuration(v)
+		if err != nil || suggestCacheTTL < 0 {
+			log.Fatalf("Invalid WEBAPP_SUGGEST_CACHE_TTL: must be a non-negative duration")
+		}
+	}
+
+	app := &Application{
+		Store: store, QuotaBudget: quotaBudget, AdminToken: adminToken, ShutdownTimeout: shutdownTimeout,
+		Suggestions: newSuggestCache(suggestCacheTTL),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	log.Printf("Starting API server on port %s", port)
-	if err := app.serve(port); err != nil {
+	err = app.serve(ctx, port)
+	if closeErr := store.Close(); closeErr != nil {
+		log.Printf("WARN: Could not close database pool: %v", closeErr)
+	}
+	if err != nil {
 		log.Fatalf("Could not start server: %v", err)
 	}
+	log.Println("API server stopped")
 }
 
-// serve mengatur router dan memulai server HTTP.
-func (app *Application) serve(port string) error {
+// serve mengatur router dan menjalankan server HTTP sampai ctx selesai. Setelah
+// itu server berhenti menerima koneksi baru dan menunggu request yang sedang
+// berjalan paling lama ShutdownTimeout.
+func (app *Application) serve(ctx context.Context, port string) error {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type"},
+		AllowedMethods:   []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -64,8 +128,26 @@ func (app *Application) serve(port string) error {
 	r.Route("/api/v1", func(r chi.Router) {
 		r.Get("/animes", app.apiListAnimesHandler)
 		r.Get("/animes/{id}", app.apiDetailAnimeHandler)
+		r.Get("/animes/{id}/stats", app.apiAnimeStatsHandler)
+		r.Get("/episodes/{videoId}/stats", app.apiEpisodeStatsHandler)
 		r.Get("/channels", app.apiChannelsHandler)
+		r.Get("/search/suggest", app.apiSuggestHandler)
 		r.Get("/top-weekly", app.apiTopWeeklyHandler)
+		r.Get("/quota", app.apiQuotaHandler)
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(app.requireAdmin)
+			r.Get("/sync-runs", app.apiSyncRunsHandler)
+			r.Get("/sync-runs/{id}", app.apiSyncRunHandler)
+			r.Get("/anime-reviews", app.apiAnimeReviewsHandler)
+			r.Post("/anime-reviews/{id}/accept", app.apiResolveAnimeReviewHandler(models.MatchAccepted))
+			r.Post("/anime-reviews/{id}/reject", app.apiResolveAnimeReviewHandler(models.MatchRejected))
+			r.Get("/animes/{id}/aliases", app.apiAnimeAliasesHandler)
+			r.Post("/animes/{id}/aliases", app.apiAddAnimeAliasHandler)
+			r.Delete("/animes/{id}/aliases/{aliasId}", app.apiDeleteAnimeAliasHandler)
+			r.Post("/animes/{id}/merge", app.apiMergeAnimeHandler)
+			r.Post("/playlists/{id}/split", app.apiSplitPlaylistHandler)
+		})
 	})
 
 	imageServer := http.FileServer(http.Dir("./web/"))
@@ -78,7 +160,29 @@ func (app *Application) serve(port string) error {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
-	return srv.ListenAndServe()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutdown signal received, draining connections for up to %s...", app.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("WARN: Could not drain all connections: %v", err)
+		srv.Close()
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // homeHandler menangani permintaan ke halaman utama.
@@ -90,6 +194,24 @@ func (app *Application) writeJSON(w http.ResponseWriter, status int, data interf
 	}
 }
 
+// requireAdmin hanya meneruskan request yang membawa header
+// "Authorization: Bearer <ADMIN_API_TOKEN>".
+func (app *Application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if app.AdminToken == "" {
+			app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Admin API is disabled"})
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			app.writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // API
 func (app *Application) apiListAnimesHandler(w http.ResponseWriter, r *http.Request) {
 	const pageSize = 24
@@ -101,19 +223,21 @@ func (app *Application) apiListAnimesHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	params := database.GetAnimesParams{
-		Search: query.Get("search"),
+		Search: strings.TrimSpace(query.Get("search")),
 		Sort:   query.Get("sort"),
 		Limit:  pageSize,
 		Offset: (page - 1) * pageSize,
+
+		IncludeUnavailable: query.Get("include_unavailable") == "true",
 	}
 
-	animes, err := app.Store.GetAnimes(params)
+	animes, err := app.Store.GetAnimes(r.Context(), params)
 	if err != nil {
 		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch animes"})
 		return
 	}
 
-	totalAnimes, _ := app.Store.CountAnimes(params)
+	totalAnimes, _ := app.Store.CountAnimes(r.Context(), params)
 	totalPages := int(math.Ceil(float64(totalAnimes) / float64(pageSize)))
 
 	response := map[string]interface{}{
@@ -133,16 +257,133 @@ func (app *Application) apiDetailAnimeHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	anime, err := app.Store.GetAnimeWithEpisodes(id)
+	includeUnavailable := r.URL.Query().Get("include_unavailable") == "true"
+	anime, err := app.Store.GetAnimeWithEpisodes(r.Context(), id, includeUnavailable)
 	if err != nil {
 		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Anime not found"})
 		return
 	}
+
+	// country (kode ISO 3166-1 alpha-2) memberi tahu klien episode mana yang bisa diputar di negaranya.
+	if country := r.URL.Query().Get("country"); country != "" {
+		if len(country) != 2 {
+			app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid country code"})
+			return
+		}
+		for _, season := range anime.Seasons {
+			for i := range season.Episodes {
+				playable := season.Episodes[i].PlayableIn(country)
+				season.Episodes[i].PlayableInCountry = &playable
+			}
+		}
+	}
 	app.writeJSON(w, http.StatusOK, anime)
 }
 
+// seriesBuckets memetakan parameter bucket ke satuan date_trunc, dengan lebar
+// bucket dan rentang default masing-masing.
+var seriesBuckets = map[string]struct {
+	unit         string
+	width        time.Duration
+	defaultRange time.Duration
+}{
+	"hourly": {"hour", time.Hour, 48 * time.Hour},
+	"daily":  {"day", 24 * time.Hour, 30 * 24 * time.Hour},
+	"weekly": {"week", 7 * 24 * time.Hour, 26 * 7 * 24 * time.Hour},
+}
+
+// maxSeriesPoints membatasi jumlah titik satu deret agar respons tetap kecil.
+const maxSeriesPoints = 1000
+
+// parseSeriesParams membaca parameter bucket (hourly, daily atau weekly;
+// default daily) dan range (misalnya 48h, 30d atau 12w) dari query string.
+// invalid berisi pesan error untuk klien jika parameter tidak valid.
+func parseSeriesParams(r *http.Request) (bucket, unit string, since time.Time, invalid string) {
+	bucket = r.URL.Query().Get("bucket")
+	if bucket == "" {
+		bucket = "daily"
+	}
+	b, ok := seriesBuckets[bucket]
+	if !ok {
+		return "", "", time.Time{}, "Invalid bucket, use hourly, daily or weekly"
+	}
+
+	span := b.defaultRange
+	if v := r.URL.Query().Get("range"); v != "" {
+		var err error
+		span, err = parseRange(v)
+		if err != nil || span <= 0 {
+			return "", "", time.Time{}, "Invalid range, use a duration such as 48h, 30d or 12w"
+		}
+	}
+	if span/b.width > maxSeriesPoints {
+		return "", "", time.Time{}, "Range is too long for this bucket"
+	}
+	return bucket, b.unit, time.Now().Add(-span), ""
+}
+
+// parseRange menerima durasi Go (48h) serta hari (30d) dan minggu (12w).
+func parseRange(v string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(v, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(v)
+}
+
+// apiAnimeStatsHandler mengembalikan deret total views anime untuk grafik.
+func (app *Application) apiAnimeStatsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anime ID"})
+		return
+	}
+	bucket, unit, since, invalid := parseSeriesParams(r)
+	if invalid != "" {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": invalid})
+		return
+	}
+
+	points, err := app.Store.GetAnimeViewSeries(r.Context(), id, unit, since)
+	if errors.Is(err, database.ErrNotFound) {
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Anime not found"})
+		return
+	}
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch anime stats"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, models.ViewSeries{Bucket: bucket, From: since, Points: points})
+}
+
+// apiEpisodeStatsHandler mengembalikan deret views satu episode untuk grafik.
+func (app *Application) apiEpisodeStatsHandler(w http.ResponseWriter, r *http.Request) {
+	videoID := chi.URLParam(r, "videoId")
+	bucket, unit, since, invalid := parseSeriesParams(r)
+	if invalid != "" {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": invalid})
+		return
+	}
+
+	points, err := app.Store.GetEpisodeViewSeries(r.Context(), videoID, unit, since)
+	if errors.Is(err, database.ErrNotFound) {
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Episode not found"})
+		return
+	}
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch episode stats"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, models.ViewSeries{Bucket: bucket, From: since, Points: points})
+}
+
 func (app *Application) apiChannelsHandler(w http.ResponseWriter, r *http.Request) {
-	channels, err := app.Store.GetAllChannelsMap()
+	channels, err := app.Store.GetAllChannelsMap(r.Context())
 	if err != nil {
 		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch channels"})
 		return
@@ -150,11 +391,264 @@ func (app *Application) apiChannelsHandler(w http.ResponseWriter, r *http.Reques
 	app.writeJSON(w, http.StatusOK, channels)
 }
 
+// trendingWindows adalah jendela yang bisa dipilih lewat parameter window di /top-weekly.
+var trendingWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// apiTopWeeklyHandler mengembalikan 10 anime dengan pertambahan views terbesar
+// selama window (24h, 7d atau 30d; default 7d).
 func (app *Application) apiTopWeeklyHandler(w http.ResponseWriter, r *http.Request) {
-	animes, err := app.Store.GetTopWeeklyAnimes()
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "7d"
+	}
+	d, ok := trendingWindows[window]
+	if !ok {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid window, use 24h, 7d or 30d"})
+		return
+	}
+
+	animes, err := app.Store.GetTrendingAnimes(r.Context(), d, 10)
 	if err != nil {
 		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch top weekly animes"})
 		return
 	}
 	app.writeJSON(w, http.StatusOK, animes)
 }
+
+func (app *Application) apiQuotaHandler(w http.ResponseWriter, r *http.Request) {
+	usage, err := youtube.LoadQuotaUsage(r.Context(), app.Store, app.QuotaBudget, time.Now())
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch quota usage"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, usage)
+}
+
+func (app *Application) apiSyncRunsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	runs, err := app.Store.GetSyncRuns(r.Context(), limit)
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch sync runs"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, runs)
+}
+
+func (app *Application) apiSyncRunHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid run ID"})
+		return
+	}
+
+	run, err := app.Store.GetSyncRun(r.Context(), id)
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch sync run"})
+		return
+	}
+	if run == nil {
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Sync run not found"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, run)
+}
+
+func (app *Application) apiAnimeReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = models.MatchPending
+	case models.MatchPending, models.MatchAccepted, models.MatchRejected:
+	default:
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid status"})
+		return
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 200 {
+			app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	reviews, err := app.Store.GetMatchReviews(r.Context(), status, limit)
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch anime reviews"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, reviews)
+}
+
+// apiResolveAnimeReviewHandler memutuskan review pencocokan anime. Untuk
+// accept, body opsional {"anime_id": N} memilih anime lain selain kandidat.
+// Worker menerapkan keputusan ini pada sinkronisasi berikutnya.
+func (app *Application) apiResolveAnimeReviewHandler(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid review ID"})
+			return
+		}
+
+		var body struct {
+			AnimeID *int `json:"anime_id"`
+		}
+		// Body kosong berarti tidak ada anime pilihan. ContentLength tidak
+		// dipakai karena bernilai -1 pada request chunked.
+		if status == models.MatchAccepted {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+				app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+				return
+			}
+		}
+
+		review, err := app.Store.ResolveMatchReview(r.Context(), id, status, body.AnimeID)
+		if errors.Is(err, database.ErrNotFound) {
+			app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Pending review not found, or the target anime does not exist"})
+			return
+		}
+		if err != nil {
+			app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to resolve anime review"})
+			return
+		}
+		app.writeJSON(w, http.StatusOK, review)
+	}
+}
+
+func (app *Application) apiAnimeAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anime ID"})
+		return
+	}
+
+	aliases, err := app.Store.GetAnimeAliases(r.Context(), id)
+	if err != nil {
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch anime aliases"})
+		return
+	}
+	app.writeJSON(w, http.StatusOK, aliases)
+}
+
+// apiAddAnimeAliasHandler menambahkan alias dari body {"alias": "..."}. Worker
+// menggabungkan playlist yang judulnya sama dengan alias ke anime ini.
+func (app *Application) apiAddAnimeAliasHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anime ID"})
+		return
+	}
+
+	var body struct {
+		Alias string `json:"alias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Alias) == "" {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body, alias is required"})
+		return
+	}
+
+	alias, err := app.Store.AddAnimeAlias(r.Context(), id, strings.TrimSpace(body.Alias))
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Anime not found"})
+	case errors.Is(err, database.ErrConflict):
+		app.writeJSON(w, http.StatusConflict, map[string]string{"error": "Alias is already used as an anime title or alias"})
+	case err != nil:
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to add anime alias"})
+	default:
+		app.writeJSON(w, http.StatusCreated, alias)
+	}
+}
+
+func (app *Application) apiDeleteAnimeAliasHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anime ID"})
+		return
+	}
+	aliasID, err := strconv.ParseInt(chi.URLParam(r, "aliasId"), 10, 64)
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid alias ID"})
+		return
+	}
+
+	err = app.Store.DeleteAnimeAlias(r.Context(), id, aliasID)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Alias not found"})
+	case err != nil:
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to delete anime alias"})
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// apiMergeAnimeHandler menggabungkan anime {id} ke anime di body {"into": N}.
+// Anime {id} dihapus dan judulnya menjadi alias anime tujuan.
+func (app *Application) apiMergeAnimeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid anime ID"})
+		return
+	}
+
+	var body struct {
+		Into int `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Into == 0 || body.Into == id {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body, into must be another anime ID"})
+		return
+	}
+
+	err = app.Store.MergeAnimes(r.Context(), id, body.Into)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Anime not found"})
+	case err != nil:
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to merge animes"})
+	default:
+		app.writeJSON(w, http.StatusOK, map[string]int{"anime_id": body.Into, "merged_anime_id": id})
+	}
+}
+
+// apiSplitPlaylistHandler memindahkan playlist {id} ke anime baru dengan judul
+// dari body {"title": "..."}. Playlist dikunci sehingga worker tidak
+// menggabungkannya kembali.
+func (app *Application) apiSplitPlaylistHandler(w http.ResponseWriter, r *http.Request) {
+	playlistID := chi.URLParam(r, "id")
+
+	var body struct {
+		Title string `json:"title"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || strings.TrimSpace(body.Title) == "" {
+		app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body, title is required"})
+		return
+	}
+
+	animeID, err := app.Store.SplitPlaylist(r.Context(), playlistID, strings.TrimSpace(body.Title))
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		app.writeJSON(w, http.StatusNotFound, map[string]string{"error": "Playlist not found"})
+	case errors.Is(err, database.ErrConflict):
+		app.writeJSON(w, http.StatusConflict, map[string]string{"error": "Title is already used as an anime title or alias"})
+	case err != nil:
+		app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to split playlist"})
+	default:
+		app.writeJSON(w, http.StatusCreated, map[string]interface{}{"anime_id": animeID, "playlist_id": playlistID})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"alyo/internal/core/models"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// minSuggestQueryLength adalah panjang kata kunci minimal sebelum saran dicari.
+	minSuggestQueryLength = 2
+	defaultSuggestLimit   = 8
+	maxSuggestLimit       = 20
+	// maxSuggestCacheEntries membatasi jumlah kata kunci yang disimpan di cache.
+	maxSuggestCacheEntries = 5000
+	// suggestQueryTimeout membatasi satu query saran; kotak pencarian akan
+	// meminta ulang di ketikan berikutnya, jadi lebih baik gagal cepat.
+	suggestQueryTimeout = 500 * time.Millisecond
+)
+
+// suggestCache menyimpan hasil saran per kata kunci selama ttl di memori
+// proses, karena banyak pengguna mengetik awalan yang sama ("one", "oshi").
+type suggestCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]suggestEntry
+}
+
+type suggestEntry struct {
+	suggestions []models.AnimeSuggestion
+	expiresAt   time.Time
+}
+
+func newSuggestCache(ttl time.Duration) *suggestCache {
+	return &suggestCache{ttl: ttl, entries: make(map[string]suggestEntry)}
+}
+
+func (c *suggestCache) get(key string) ([]models.AnimeSuggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.suggestions, true
+}
+
+// put menyimpan hasil saran. Jika cache penuh, entri yang kedaluwarsa dibuang
+// dulu; jika masih penuh, seluruh cache dikosongkan.
+func (c *suggestCache) put(key string, suggestions []models.AnimeSuggestion) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxSuggestCacheEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxSuggestCacheEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = suggestEntry{suggestions: suggestions, expiresAt: now.Add(c.ttl)}
+}
+
+// apiSuggestHandler mengembalikan beberapa anime yang cocok dengan kata kunci
+// yang sedang diketik di kotak pencarian: cukup ID, judul dan thumbnail.
+func (app *Application) apiSuggestHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.Join(strings.Fields(r.URL.Query().Get("q")), " "))
+
+	limit := defaultSuggestLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestLimit {
+			app.writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Invalid limit, must be between 1 and %d", maxSuggestLimit)})
+			return
+		}
+		limit = n
+	}
+
+	if len([]rune(query)) < minSuggestQueryLength {
+		app.writeJSON(w, http.StatusOK, []models.AnimeSuggestion{})
+		return
+	}
+
+	key := strconv.Itoa(limit) + ":" + query
+	suggestions, ok := app.Suggestions.get(key)
+	if !ok {
+		ctx, cancel := context.WithTimeout(r.Context(), suggestQueryTimeout)
+		defer cancel()
+		var err error
+		suggestions, err = app.Store.SuggestAnimes(ctx, query, limit)
+		if err != nil {
+			app.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fetch suggestions"})
+			return
+		}
+		app.Suggestions.put(key, suggestions)
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(app.Suggestions.ttl.Seconds())))
+	app.writeJSON(w, http.StatusOK, suggestions)
+}
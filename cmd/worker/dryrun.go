@@ -0,0 +1,408 @@
+package main
+
+import (
+	"alyo/internal/core/database"
+	"alyo/internal/core/models"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// planPrinter mencetak satu baris rencana perubahan per aksi pada mode dry run.
+type planPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *planPrinter) print(action, kind, format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, "%-9s %-9s %s\n", action, kind, fmt.Sprintf(format, args...))
+}
+
+// dryRunStore membungkus Store untuk mode dry run: semua pembacaan diteruskan
+// ke database, sedangkan setiap penulisan hanya dicetak sebagai rencana
+// (INSERT, UPDATE, RENAME, MERGE, REMOVE) tanpa mengubah apa pun. Pemakaian
+// quota tetap dicatat karena request ke YouTube pada dry run memang memakai quota.
+//
+// Store tidak di-embed: setiap method diteruskan secara eksplisit, jadi method
+// baru di database.Store tidak bisa diam-diam menulis ke database sebelum
+// ditambahkan di sini.
+type dryRunStore struct {
+	store database.Store
+	plan  *planPrinter
+
+	mu sync.Mutex
+	// animes berisi judul anime yang sudah dikenal selama run, termasuk anime
+	// baru yang hanya ada di rencana (dengan ID negatif).
+	animes      map[int]string
+	nextAnimeID int
+}
+
+func newDryRunStore(store database.Store, plan *planPrinter) *dryRunStore {
+	return &dryRunStore{store: store, plan: plan, animes: make(map[int]string)}
+}
+
+// FindAnimeByTitle juga menemukan anime yang baru akan dibuat di run ini.
+func (s *dryRunStore) FindAnimeByTitle(ctx context.Context, title string) (*models.Anime, error) {
+	s.mu.Lock()
+	for id, t := range s.animes {
+		if t == title {
+			s.mu.Unlock()
+			return &models.Anime{ID: id, Title: title}, nil
+		}
+	}
+	s.mu.Unlock()
+
+	anime, err := s.store.FindAnimeByTitle(ctx, title)
+	if anime != nil {
+		s.mu.Lock()
+		s.animes[anime.ID] = anime.Title
+		s.mu.Unlock()
+	}
+	return anime, err
+}
+
+// ListAnimeTitles juga menyertakan anime yang baru akan dibuat di run ini.
+func (s *dryRunStore) ListAnimeTitles(ctx context.Context) ([]models.AnimeTitle, error) {
+	animeTitles, err := s.store.ListAnimeTitles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, title := range s.animes {
+		if id < 0 {
+			animeTitles = append(animeTitles, models.AnimeTitle{ID: id, Title: title})
+		}
+	}
+	return animeTitles, nil
+}
+
+func (s *dryRunStore) UpsertAnime(ctx context.Context, anime models.Anime) (int, error) {
+	s.mu.Lock()
+	s.nextAnimeID--
+	id := s.nextAnimeID
+	s.animes[id] = anime.Title
+	s.mu.Unlock()
+	s.plan.print("INSERT", "anime", "%q", anime.Title)
+	return id, nil
+}
+
+func (s *dryRunStore) animeLabel(id *int) string {
+	if id == nil {
+		return "(none)"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if title, ok := s.animes[*id]; ok {
+		if *id < 0 {
+			return fmt.Sprintf("%q (new)", title)
+		}
+		return fmt.Sprintf("%q (#%d)", title, *id)
+	}
+	return fmt.Sprintf("#%d", *id)
+}
+
+// UpsertSeason tidak dicetak; season playlist sudah tercantum di baris SYNC.
+func (s *dryRunStore) UpsertSeason(ctx context.Context, season models.Season) (int, error) {
+	return 0, nil
+}
+
+func (s *dryRunStore) UpsertChannel(ctx context.Context, channel models.Channel) error {
+	s.plan.print("UPSERT", "channel", "%s %q", channel.ID, channel.Name)
+	return nil
+}
+
+func (s *dryRunStore) UpsertPlaylist(ctx context.Context, playlist models.Playlist) error {
+	existing, err := s.store.GetPlaylist(ctx, playlist.ID)
+	if err != nil {
+		return err
+	}
+	anime := s.animeLabel(playlist.AnimeID)
+	switch {
+	case existing == nil:
+		action := "INSERT"
+		// Judul playlist yang cocok dengan anime yang sudah ada berarti playlist digabung ke anime itu.
+		if playlist.AnimeID != nil && *playlist.AnimeID > 0 {
+			action = "MERGE"
+		}
+		s.plan.print(action, "playlist", "%s %q [%s] -> anime %s", playlist.ID, playlist.Title, playlist.Language, anime)
+	case existing.AnimeID == nil || playlist.AnimeID == nil || *existing.AnimeID != *playlist.AnimeID:
+		s.plan.print("MERGE", "playlist", "%s %q moves from anime %s to %s", playlist.ID, playlist.Title, s.animeLabel(existing.AnimeID), anime)
+	case existing.Title != playlist.Title:
+		s.plan.print("RENAME", "playlist", "%s %q -> %q", playlist.ID, existing.Title, playlist.Title)
+	case existing.Language != playlist.Language:
+		s.plan.print("UPDATE", "playlist", "%s %q language %s -> %s", playlist.ID, playlist.Title, existing.Language, playlist.Language)
+	}
+	return nil
+}
+
+func (s *dryRunStore) UpsertEpisode(ctx context.Context, episode models.Episode) (bool, error) {
+	existing, err := s.store.GetEpisode(ctx, episode.VideoID)
+	if err != nil {
+		return false, err
+	}
+	epNum := "?"
+	if episode.EpisodeNumber != nil {
+		epNum = fmt.Sprint(*episode.EpisodeNumber)
+	}
+	switch {
+	case existing == nil:
+		s.plan.print("INSERT", "episode", "%s ep %s %q", episode.VideoID, epNum, episode.Title)
+		return true, nil
+	case existing.Title != episode.Title:
+		s.plan.print("RENAME", "episode", "%s %q -> %q", episode.VideoID, existing.Title, episode.Title)
+	case existing.UnavailableAt != nil:
+		s.plan.print("RESTORE", "episode", "%s %q", episode.VideoID, episode.Title)
+	}
+	return false, nil
+}
+
+func (s *dryRunStore) CreateMatchReview(ctx context.Context, review models.AnimeMatchReview) error {
+	s.plan.print("REVIEW", "playlist", "%s %q -> anime %q? (score %.2f)", review.PlaylistID, review.PlaylistTitle, *review.CandidateTitle, review.Score)
+	return nil
+}
+
+func (s *dryRunStore) MarkEpisodeUnavailable(ctx context.Context, videoID string, reason string) error {
+	existing, err := s.store.GetEpisode(ctx, videoID)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.UnavailableAt == nil {
+		s.plan.print("REMOVE", "episode", "%s %q (%s)", videoID, existing.Title, reason)
+	}
+	return nil
+}
+
+func (s *dryRunStore) MarkMissingEpisodesUnavailable(ctx context.Context, playlistID string, presentVideoIDs []string) (int64, error) {
+	episodes, err := s.store.GetEpisodesForPlaylist(ctx, playlistID)
+	if err != nil {
+		return 0, err
+	}
+	present := make(map[string]bool, len(presentVideoIDs))
+	for _, id := range presentVideoIDs {
+		present[id] = true
+	}
+	var n int64
+	for _, e := range episodes {
+		if e.UnavailableAt == nil && !present[e.VideoID] {
+			s.plan.print("REMOVE", "episode", "%s %q (%s)", e.VideoID, e.Title, models.EpisodeRemoved)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *dryRunStore) UpdateEpisodeStatistics(ctx context.Context, stats []models.EpisodeStatistics) (int64, error) {
+	s.plan.print("UPDATE", "episode", "statistics of %d episode(s)", len(stats))
+	return 0, nil
+}
+
+func (s *dryRunStore) RecomputeAnimeAggregates(ctx context.Context, animeIDs []int) (int64, error) {
+	if animeIDs == nil {
+		s.plan.print("UPDATE", "anime", "views, last update and thumbnail of every anime recomputed from its episodes")
+	} else {
+		s.plan.print("UPDATE", "anime", "views, last update and thumbnail of %d anime(s) recomputed from their episodes", len(animeIDs))
+	}
+	return 0, nil
+}
+
+func (s *dryRunStore) SaveViewSnapshots(ctx context.Context, stats []models.EpisodeStatistics) error {
+	s.plan.print("INSERT", "snapshot", "views of %d episode(s) and every anime", len(stats))
+	return nil
+}
+
+func (s *dryRunStore) DownsampleViewSnapshots(ctx context.Context, keepHourly, keepDaily time.Duration) (int64, error) {
+	s.plan.print("REMOVE", "snapshot", "all but one per day after %s and one per week after %s", keepHourly, keepDaily)
+	return 0, nil
+}
+
+func (s *dryRunStore) PruneCachedResponses(ctx context.Context, olderThan time.Duration) (int64, error) {
+	s.plan.print("REMOVE", "etag", "cached responses not updated for %s", olderThan)
+	return 0, nil
+}
+
+// ReserveQuota diteruskan karena request ke YouTube pada dry run tetap memakai quota.
+func (s *dryRunStore) ReserveQuota(ctx context.Context, day string, endpoint string, keyLabel string, units int, budget int64) (int64, bool, error) {
+	return s.store.ReserveQuota(ctx, day, endpoint, keyLabel, units, budget)
+}
+
+// Penulisan berikut tidak memengaruhi katalog yang terlihat, jadi diabaikan tanpa dicetak.
+
+func (s *dryRunStore) ResolveMatchReview(ctx context.Context, reviewID int64, status string, animeID *int) (*models.AnimeMatchReview, error) {
+	return nil, nil
+}
+
+func (s *dryRunStore) AddAnimeAlias(ctx context.Context, animeID int, alias string) (*models.AnimeAlias, error) {
+	return nil, nil
+}
+
+func (s *dryRunStore) DeleteAnimeAlias(ctx context.Context, animeID int, aliasID int64) error {
+	return nil
+}
+
+func (s *dryRunStore) MergeAnimes(ctx context.Context, sourceID, targetID int) error {
+	return nil
+}
+
+func (s *dryRunStore) SplitPlaylist(ctx context.Context, playlistID string, title string) (int, error) {
+	return 0, nil
+}
+
+func (s *dryRunStore) AddChannel(ctx context.Context, channel models.Channel) error {
+	return nil
+}
+
+func (s *dryRunStore) SetChannelEnabled(ctx context.Context, channelID string, enabled bool) error {
+	return nil
+}
+
+func (s *dryRunStore) SavePlaylistSyncState(ctx context.Context, state models.PlaylistSyncState) error {
+	return nil
+}
+
+func (s *dryRunStore) CreateSyncRun(ctx context.Context, task string, startedAt time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (s *dryRunStore) FinishSyncRun(ctx context.Context, run models.SyncRun) error {
+	return nil
+}
+
+func (s *dryRunStore) SaveSyncRunChannel(ctx context.Context, channel models.SyncRunChannel) error {
+	return nil
+}
+
+func (s *dryRunStore) SaveSyncRunPlaylist(ctx context.Context, playlist models.SyncRunPlaylist) error {
+	return nil
+}
+
+// Dry run tidak menulis apa pun, jadi tidak perlu bersaing dengan worker lain.
+func (s *dryRunStore) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	return models.Lease{Name: name, Holder: holder}, true, nil
+}
+
+func (s *dryRunStore) RenewLease(ctx context.Context, name string, holder string, ttl time.Duration) error {
+	return nil
+}
+
+func (s *dryRunStore) ReleaseLease(ctx context.Context, name string, holder string) error {
+	return nil
+}
+
+func (s *dryRunStore) SaveCachedResponse(ctx context.Context, resourceKey string, etag string, body []byte) error {
+	return nil
+}
+
+func (s *dryRunStore) DeleteCachedResponse(ctx context.Context, resourceKey string) error {
+	return nil
+}
+
+func (s *dryRunStore) Close() error {
+	return s.store.Close()
+}
+
+// Pembacaan berikut diteruskan apa adanya ke database.
+
+func (s *dryRunStore) GetPlaylist(ctx context.Context, playlistID string) (*models.Playlist, error) {
+	return s.store.GetPlaylist(ctx, playlistID)
+}
+
+func (s *dryRunStore) GetPlaylistsWithoutSeason(ctx context.Context) ([]models.Playlist, error) {
+	return s.store.GetPlaylistsWithoutSeason(ctx)
+}
+
+func (s *dryRunStore) GetEpisode(ctx context.Context, videoID string) (*models.Episode, error) {
+	return s.store.GetEpisode(ctx, videoID)
+}
+
+func (s *dryRunStore) GetEpisodesForPlaylist(ctx context.Context, playlistID string) ([]models.Episode, error) {
+	return s.store.GetEpisodesForPlaylist(ctx, playlistID)
+}
+
+func (s *dryRunStore) GetAllAnimes(ctx context.Context) ([]models.Anime, error) {
+	return s.store.GetAllAnimes(ctx)
+}
+
+func (s *dryRunStore) GetMatchReviewForPlaylist(ctx context.Context, playlistID string) (*models.AnimeMatchReview, error) {
+	return s.store.GetMatchReviewForPlaylist(ctx, playlistID)
+}
+
+func (s *dryRunStore) GetMatchReviews(ctx context.Context, status string, limit int) ([]models.AnimeMatchReview, error) {
+	return s.store.GetMatchReviews(ctx, status, limit)
+}
+
+func (s *dryRunStore) GetAnimeAliases(ctx context.Context, animeID int) ([]models.AnimeAlias, error) {
+	return s.store.GetAnimeAliases(ctx, animeID)
+}
+
+func (s *dryRunStore) GetAnimeWithEpisodes(ctx context.Context, animeID int, includeUnavailable bool) (*models.AnimeWithEpisodes, error) {
+	return s.store.GetAnimeWithEpisodes(ctx, animeID, includeUnavailable)
+}
+
+func (s *dryRunStore) GetAnimes(ctx context.Context, params database.GetAnimesParams) ([]models.Anime, error) {
+	return s.store.GetAnimes(ctx, params)
+}
+
+func (s *dryRunStore) CountAnimes(ctx context.Context, params database.GetAnimesParams) (int, error) {
+	return s.store.CountAnimes(ctx, params)
+}
+
+func (s *dryRunStore) SuggestAnimes(ctx context.Context, query string, limit int) ([]models.AnimeSuggestion, error) {
+	return s.store.SuggestAnimes(ctx, query, limit)
+}
+
+func (s *dryRunStore) GetAvailableEpisodeIDs(ctx context.Context, channelIDs []string) ([]string, error) {
+	return s.store.GetAvailableEpisodeIDs(ctx, channelIDs)
+}
+
+func (s *dryRunStore) GetTrendingAnimes(ctx context.Context, window time.Duration, limit int) ([]models.Anime, error) {
+	return s.store.GetTrendingAnimes(ctx, window, limit)
+}
+
+func (s *dryRunStore) GetAnimeViewSeries(ctx context.Context, animeID int, bucket string, since time.Time) ([]models.ViewPoint, error) {
+	return s.store.GetAnimeViewSeries(ctx, animeID, bucket, since)
+}
+
+func (s *dryRunStore) GetEpisodeViewSeries(ctx context.Context, videoID string, bucket string, since time.Time) ([]models.ViewPoint, error) {
+	return s.store.GetEpisodeViewSeries(ctx, videoID, bucket, since)
+}
+
+func (s *dryRunStore) GetAllChannelsMap(ctx context.Context) (map[string]models.Channel, error) {
+	return s.store.GetAllChannelsMap(ctx)
+}
+
+func (s *dryRunStore) ListChannels(ctx context.Context) ([]models.Channel, error) {
+	return s.store.ListChannels(ctx)
+}
+
+func (s *dryRunStore) GetEnabledChannels(ctx context.Context) ([]models.Channel, error) {
+	return s.store.GetEnabledChannels(ctx)
+}
+
+func (s *dryRunStore) GetPlaylistSyncState(ctx context.Context, playlistID string) (*models.PlaylistSyncState, error) {
+	return s.store.GetPlaylistSyncState(ctx, playlistID)
+}
+
+func (s *dryRunStore) GetSyncRuns(ctx context.Context, limit int) ([]models.SyncRun, error) {
+	return s.store.GetSyncRuns(ctx, limit)
+}
+
+func (s *dryRunStore) GetSyncRun(ctx context.Context, runID int64) (*models.SyncRun, error) {
+	return s.store.GetSyncRun(ctx, runID)
+}
+
+func (s *dryRunStore) GetQuotaUsage(ctx context.Context, day string) (map[string]int64, error) {
+	return s.store.GetQuotaUsage(ctx, day)
+}
+
+func (s *dryRunStore) GetQuotaUsageByKey(ctx context.Context, day string) (map[string]int64, error) {
+	return s.store.GetQuotaUsageByKey(ctx, day)
+}
+
+func (s *dryRunStore) GetCachedResponse(ctx context.Context, resourceKey string) (string, []byte, error) {
+	return s.store.GetCachedResponse(ctx, resourceKey)
+}
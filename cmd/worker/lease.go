@@ -0,0 +1,162 @@
+package main
+
+import (
+	"alyo/internal/core/database"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// syncLeaseName adalah nama lease yang dipegang task yang menulis playlist dan
+// episode (discovery dan reconcile), sehingga keduanya tidak pernah berjalan bersamaan.
+const syncLeaseName = "sync"
+
+// errLeaseBusy dikembalikan withLease saat lease sedang dipegang instance lain.
+var errLeaseBusy = errors.New("lease is held by another instance")
+
+// Metrik lease, tersedia di /debug/vars jika WORKER_METRICS_ADDR diisi.
+var (
+	leaseAcquired  = expvar.NewInt("worker_lease_acquired")
+	leaseContended = expvar.NewInt("worker_lease_contended")
+	leaseLost      = expvar.NewInt("worker_lease_lost")
+	leasesHeld     = expvar.NewMap("worker_leases_held")
+)
+
+// newHolderID membuat identitas unik instance worker ini untuk lease. Suffix
+// acak membedakan replika yang kebetulan punya hostname dan PID yang sama
+// (misalnya PID 1 di dalam container).
+func newHolderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// localLocks mengantrekan task dengan lease yang sama di dalam satu proses.
+// Lease di database re-entrant untuk holder yang sama, jadi tidak mencegah dua
+// task di instance ini memegang lease yang sama sekaligus.
+type localLocks struct {
+	mu    sync.Mutex
+	locks map[string]chan struct{}
+}
+
+// lock menunggu sampai lock name bebas, lalu mengembalikan fungsi untuk
+// melepasnya. Menunggu dihentikan saat ctx selesai atau stopping ditutup.
+func (l *localLocks) lock(ctx context.Context, stopping <-chan struct{}, name string) (unlock func(), err error) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]chan struct{})
+	}
+	ch, ok := l.locks[name]
+	if !ok {
+		ch = make(chan struct{}, 1)
+		l.locks[name] = ch
+	}
+	l.mu.Unlock()
+
+	select {
+	case ch <- struct{}{}:
+		return func() { <-ch }, nil
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	case <-stopping:
+		return nil, errShuttingDown
+	}
+}
+
+// withLease menjalankan fn hanya jika instance ini berhasil mengambil lease
+// name, lalu mengembalikan error dari fn. Task lain di proses ini yang memakai
+// lease yang sama ditunggu dulu sampai selesai. Selama fn berjalan lease
+// diperbarui secara berkala; jika lease hilang (misalnya database tidak bisa
+// dihubungi lebih lama dari LeaseTTL dan instance lain mengambil alih), ctx
+// milik fn dibatalkan dengan ErrLeaseLost. Mengembalikan errLeaseBusy jika
+// lease sedang dipegang instance lain.
+func (app *AppConfig) withLease(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	unlock, err := app.locks.lock(ctx, app.stopping, name)
+	if err != nil {
+		log.Printf("INFO: Stopped waiting for %s lease: %v", name, err)
+		return err
+	}
+	defer unlock()
+
+	lease, acquired, err := app.Store.AcquireLease(ctx, name, app.HolderID, app.LeaseTTL)
+	if err != nil {
+		log.Printf("ERROR: Could not acquire %s lease: %v", name, err)
+		return fmt.Errorf("could not acquire %s lease: %w", name, err)
+	}
+	if !acquired {
+		leaseContended.Add(1)
+		log.Printf("INFO: %s lease is held by %s (heartbeat %s, expires %s), skipping this run", name,
+			lease.Holder, lease.HeartbeatAt.Format(time.RFC3339), lease.ExpiresAt.Format(time.RFC3339))
+		return errLeaseBusy
+	}
+	leaseAcquired.Add(1)
+	holder := new(expvar.String)
+	holder.Set(app.HolderID)
+	leasesHeld.Set(name, holder)
+	log.Printf("INFO: Acquired %s lease as %s", name, app.HolderID)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		app.heartbeat(ctx, name, cancel)
+	}()
+	// Lease juga dilepas saat fn panic, agar instance lain tidak perlu
+	// menunggu sampai lease kedaluwarsa.
+	defer func() {
+		cancel(nil)
+		<-done
+		leasesHeld.Delete(name)
+		if err := app.Store.ReleaseLease(context.WithoutCancel(ctx), name, app.HolderID); err != nil {
+			log.Printf("WARN: Could not release %s lease, it will expire in %s: %v", name, app.LeaseTTL, err)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// heartbeat memperbarui lease name setiap sepertiga LeaseTTL sampai ctx selesai.
+// Kegagalan sementara dicoba lagi pada detak berikutnya; run dibatalkan hanya
+// jika lease sudah diambil instance lain atau tidak bisa diperbarui sebelum kedaluwarsa.
+func (app *AppConfig) heartbeat(ctx context.Context, name string, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(app.LeaseTTL / 3)
+	defer ticker.Stop()
+	lastRenewed := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := app.Store.RenewLease(ctx, name, app.HolderID, app.LeaseTTL)
+		switch {
+		case err == nil:
+			lastRenewed = time.Now()
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, database.ErrLeaseLost) || time.Since(lastRenewed) >= app.LeaseTTL:
+			leaseLost.Add(1)
+			log.Printf("ERROR: Lost %s lease, stopping the current run: %v", name, err)
+			if !errors.Is(err, database.ErrLeaseLost) {
+				err = fmt.Errorf("%w: %v", database.ErrLeaseLost, err)
+			}
+			cancel(err)
+			return
+		default:
+			log.Printf("WARN: Could not renew %s lease, retrying: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"alyo/internal/core/database"
+	"alyo/internal/core/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newLeaseApp(store *memStore, holder string) *AppConfig {
+	return &AppConfig{Store: store, HolderID: holder, LeaseTTL: time.Minute}
+}
+
+func TestWithLeaseBusy(t *testing.T) {
+	store := newMemStore()
+	other := newLeaseApp(store, "worker-b")
+	app := newLeaseApp(store, "worker-a")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	otherDone := make(chan error, 1)
+	go func() {
+		otherDone <- other.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ran := false
+	err := app.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	if !errors.Is(err, errLeaseBusy) || ran {
+		t.Errorf("withLease while held elsewhere = %v (ran %v), want %v without running", err, ran, errLeaseBusy)
+	}
+
+	close(release)
+	if err := <-otherDone; err != nil {
+		t.Fatalf("holder: %v", err)
+	}
+	if err := app.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("withLease after release = %v, want nil", err)
+	}
+}
+
+func TestWithLeaseLost(t *testing.T) {
+	store := newMemStore()
+	app := newLeaseApp(store, "worker-a")
+	app.LeaseTTL = 30 * time.Millisecond
+
+	err := app.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error {
+		// Instance lain mengambil alih lease, jadi heartbeat berikutnya tidak
+		// memperbarui satu baris pun.
+		store.mu.Lock()
+		store.leases[syncLeaseName] = models.Lease{Name: syncLeaseName, Holder: "worker-b", ExpiresAt: time.Now().Add(time.Hour)}
+		store.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(5 * time.Second):
+			return errors.New("context was not cancelled")
+		}
+	})
+	if !errors.Is(err, database.ErrLeaseLost) {
+		t.Errorf("withLease error = %v, want %v", err, database.ErrLeaseLost)
+	}
+	if lease := store.leases[syncLeaseName]; lease.Holder != "worker-b" {
+		t.Errorf("lease holder = %q, want the new holder to keep it", lease.Holder)
+	}
+}
+
+// TestWithLeaseLocalLock memeriksa bahwa task dengan lease yang sama di proses
+// yang sama tidak pernah berjalan bersamaan, walaupun lease di database
+// re-entrant untuk holder yang sama.
+func TestWithLeaseLocalLock(t *testing.T) {
+	store := newMemStore()
+	app := newLeaseApp(store, "worker-a")
+	stopping := make(chan struct{})
+	app.stopping = stopping
+
+	started, release := make(chan struct{}), make(chan struct{})
+	firstDone := make(chan error, 1)
+	go func() {
+		firstDone <- app.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ran := false
+	second := func(ctx context.Context) error {
+		ran = true
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := app.withLease(ctx, syncLeaseName, second); !errors.Is(err, context.DeadlineExceeded) || ran {
+		t.Errorf("concurrent withLease = %v (ran %v), want %v without running", err, ran, context.DeadlineExceeded)
+	}
+
+	close(stopping)
+	if err := app.withLease(context.Background(), syncLeaseName, second); !errors.Is(err, errShuttingDown) || ran {
+		t.Errorf("withLease while stopping = %v (ran %v), want %v without running", err, ran, errShuttingDown)
+	}
+
+	close(release)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+}
+
+func TestWithLeaseReleases(t *testing.T) {
+	errTask := errors.New("task failed")
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context) error
+	}{
+		{"success", func(ctx context.Context) error { return nil }},
+		{"error", func(ctx context.Context) error { return errTask }},
+		{"panic", func(ctx context.Context) error { panic("task panicked") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			app := newLeaseApp(store, "worker-a")
+
+			func() {
+				defer func() { recover() }()
+				err := app.withLease(context.Background(), syncLeaseName, tt.fn)
+				if tt.name == "error" && !errors.Is(err, errTask) {
+					t.Errorf("withLease error = %v, want %v", err, errTask)
+				}
+			}()
+
+			if lease, ok := store.leases[syncLeaseName]; ok {
+				t.Errorf("lease still held by %s", lease.Holder)
+			}
+			// Lock lokal juga sudah dilepas.
+			if err := app.withLease(context.Background(), syncLeaseName, func(ctx context.Context) error { return nil }); err != nil {
+				t.Errorf("withLease after %s = %v, want nil", tt.name, err)
+			}
+		})
+	}
+}
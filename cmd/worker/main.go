@@ -3,16 +3,25 @@ package main
 import (
 	"alyo/internal/core/database"
 	"alyo/internal/core/models"
+	"alyo/internal/pipeline"
+	"alyo/internal/titles"
 	"alyo/internal/youtube"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -22,222 +31,1119 @@ import (
 type AppConfig struct {
 	Store         database.Store
 	YouTubeClient *youtube.Client
-}
+	RunTimeout    time.Duration
+
+	// MinEpisodeDuration adalah durasi minimum video untuk dianggap episode.
+	MinEpisodeDuration time.Duration
+
+	// FullSyncInterval adalah umur maksimum sinkronisasi penuh terakhir satu
+	// playlist sebelum task discovery ikut membacanya penuh, sebagai cadangan
+	// jika task reconcile terlewat. 0 berarti selalu penuh.
+	FullSyncInterval time.Duration
+
+	// HolderID mengidentifikasi instance ini pada lease sinkronisasi, dan
+	// LeaseTTL adalah lama lease berlaku tanpa heartbeat sebelum dianggap mati.
+	HolderID string
+	LeaseTTL time.Duration
+
+	// Jumlah pekerjaan paralel maksimum untuk setiap tahap ingest.
+	ChannelConcurrency    int
+	PlaylistConcurrency   int
+	VideoBatchConcurrency int
+
+	// Judul playlist dengan skor kemiripan minimal MatchAutoThreshold digabung
+	// otomatis ke anime yang sudah ada; skor antara MatchReviewThreshold dan
+	// MatchAutoThreshold masuk antrean review.
+	MatchAutoThreshold   float64
+	MatchReviewThreshold float64
 
-var targetChannels = map[string]string{
-	"Muse Indonesia": "UCxxnxya_32jcKj4yN1_kD7A",
-	"Ani-One Asia":   "UC0wNSTMWIL3qaorLx0jie6A",
-	"Muse Asia":      "UCGbshtvS9t-8CW11W7TooQg",
+	// Snapshot views yang lebih tua dari SnapshotKeepHourly disisakan satu per
+	// hari, dan yang lebih tua dari SnapshotKeepDaily satu per minggu.
+	SnapshotKeepHourly time.Duration
+	SnapshotKeepDaily  time.Duration
+	// ETagRetention adalah umur maksimum respons YouTube tersimpan (ETag cache)
+	// yang tidak diperbarui sebelum dihapus task downsample.
+	ETagRetention time.Duration
+
+	// OnlyChannel dan OnlyPlaylist membatasi run ke satu channel atau satu playlist.
+	OnlyChannel  string
+	OnlyPlaylist string
+	// Plan terisi pada mode dry run untuk mencetak klasifikasi playlist.
+	Plan *planPrinter
+
+	locks localLocks
+	// stopping ditutup saat worker mulai dimatikan; task yang masih antre tidak dimulai lagi.
+	stopping <-chan struct{}
 }
 
 func main() {
+	if err := runWorker(); err != nil {
+		log.Printf("ERROR: %v", err)
+		os.Exit(1)
+	}
+}
+
+// runWorker menjalankan worker sampai menerima sinyal berhenti, atau sekali
+// saja pada mode -once. Error dikembalikan setelah semua cleanup (lease, pool
+// database) selesai, dan main keluar dengan exit code 1.
+func runWorker() error {
+	once := flag.Bool("once", false, "run the startup tasks once and exit instead of starting the scheduler")
+	taskName := flag.String("task", "", "run only this task once: discovery, reconcile, views, avatars or downsample (implies -once)")
+	onlyChannel := flag.String("channel", "", "sync only this channel ID (implies -once)")
+	onlyPlaylist := flag.String("playlist", "", "sync only this playlist ID (implies -once)")
+	dryRun := flag.Bool("dry-run", false, "fetch and classify playlists and print planned changes without writing to the database (implies -once)")
+	flag.Parse()
+	if *taskName != "" || *onlyChannel != "" || *onlyPlaylist != "" || *dryRun {
+		*once = true
+	}
+
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found")
 	}
 
 	dbURL := os.Getenv("DATABASE_URL")
-	apiKey := os.Getenv("YOUTUBE_API_KEY")
-	if dbURL == "" || apiKey == "" {
-		log.Fatal("DATABASE_URL and YOUTUBE_API_KEY must be set")
+	apiKeys := youtube.ParseAPIKeys(os.Getenv("YOUTUBE_API_KEYS"), os.Getenv("YOUTUBE_API_KEY"))
+	cassettePath := os.Getenv("YOUTUBE_CASSETTE")
+	cassetteMode := youtube.CassetteMode(os.Getenv("YOUTUBE_CASSETTE_MODE"))
+	// Mode replay tidak pernah menghubungi YouTube, jadi API key tidak diperlukan.
+	replaying := cassettePath != "" && cassetteMode == youtube.CassetteReplay
+	if dbURL == "" || (len(apiKeys) == 0 && !replaying) {
+		log.Fatal("DATABASE_URL and YOUTUBE_API_KEYS (or YOUTUBE_API_KEY) must be set")
+	}
+	if len(apiKeys) == 0 {
+		apiKeys = []string{"replay"}
 	}
 
 	store, err := database.NewDBStore(dbURL)
 	if err != nil {
 		log.Fatalf("Could not connect to the database: %v", err)
 	}
+	var plan *planPrinter
+	if *dryRun {
+		log.Println("Dry run: planned changes are printed to stdout, nothing is written to the database")
+		plan = &planPrinter{w: os.Stdout}
+		store = newDryRunStore(store, plan)
+	}
+
+	// Setiap key berasal dari project sendiri dengan quota hariannya masing-masing.
+	quotaBudget := int64(youtube.DefaultDailyBudget * len(apiKeys))
+	if v := os.Getenv("YOUTUBE_DAILY_QUOTA_BUDGET"); v != "" {
+		quotaBudget, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid YOUTUBE_DAILY_QUOTA_BUDGET: %v", err)
+		}
+	}
+
+	runTimeout := 2 * time.Hour
+	if v := os.Getenv("WORKER_RUN_TIMEOUT"); v != "" {
+		runTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_RUN_TIMEOUT: %v", err)
+		}
+	}
+
+	minEpisodeDuration := 3 * time.Minute
+	if v := os.Getenv("WORKER_MIN_EPISODE_DURATION"); v != "" {
+		minEpisodeDuration, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_MIN_EPISODE_DURATION: %v", err)
+		}
+	}
+
+	fullSyncInterval := 48 * time.Hour
+	if v := os.Getenv("WORKER_FULL_SYNC_INTERVAL"); v != "" {
+		fullSyncInterval, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_FULL_SYNC_INTERVAL: %v", err)
+		}
+	}
+
+	shutdownTimeout := 30 * time.Second
+	if v := os.Getenv("WORKER_SHUTDOWN_TIMEOUT"); v != "" {
+		shutdownTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_SHUTDOWN_TIMEOUT: %v", err)
+		}
+	}
+
+	leaseTTL := 2 * time.Minute
+	if v := os.Getenv("WORKER_LEASE_TTL"); v != "" {
+		leaseTTL, err = time.ParseDuration(v)
+		if err != nil || leaseTTL < 3*time.Second {
+			log.Fatalf("Invalid WORKER_LEASE_TTL: must be a duration of at least 3s")
+		}
+	}
+
+	channelConcurrency := envInt("WORKER_CHANNEL_CONCURRENCY", 2)
+	playlistConcurrency := envInt("WORKER_PLAYLIST_CONCURRENCY", 4)
+	videoBatchConcurrency := envInt("WORKER_VIDEO_BATCH_CONCURRENCY", 2)
+
+	matchAutoThreshold := envFraction("WORKER_MATCH_AUTO_THRESHOLD", 0.9)
+	matchReviewThreshold := envFraction("WORKER_MATCH_REVIEW_THRESHOLD", 0.6)
+	if matchReviewThreshold > matchAutoThreshold {
+		log.Fatalf("Invalid WORKER_MATCH_REVIEW_THRESHOLD: must not be greater than WORKER_MATCH_AUTO_THRESHOLD")
+	}
+
+	snapshotKeepHourly := 8 * 24 * time.Hour
+	if v := os.Getenv("WORKER_SNAPSHOT_KEEP_HOURLY"); v != "" {
+		snapshotKeepHourly, err = time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("Invalid WORKER_SNAPSHOT_KEEP_HOURLY: %v", err)
+		}
+	}
+	snapshotKeepDaily := 90 * 24 * time.Hour
+	if v := os.Getenv("WORKER_SNAPSHOT_KEEP_DAILY"); v != "" {
+		snapshotKeepDaily, err = time.ParseDuration(v)
+		if err != nil || snapshotKeepDaily < snapshotKeepHourly {
+			log.Fatalf("Invalid WORKER_SNAPSHOT_KEEP_DAILY: must be a duration not shorter than WORKER_SNAPSHOT_KEEP_HOURLY")
+		}
+	}
+
+	etagRetention := 7 * 24 * time.Hour
+	if v := os.Getenv("WORKER_ETAG_RETENTION"); v != "" {
+		etagRetention, err = time.ParseDuration(v)
+		if err != nil || etagRetention <= 0 {
+			log.Fatalf("Invalid WORKER_ETAG_RETENTION: must be a positive duration")
+		}
+	}
 
-	ytClient := youtube.NewClient(apiKey)
+	// Satu limiter dipakai bersama semua tahap, menggantikan jeda tetap antar playlist.
+	requestsPerSecond := 5.0
+	if v := os.Getenv("YOUTUBE_REQUESTS_PER_SECOND"); v != "" {
+		requestsPerSecond, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("Invalid YOUTUBE_REQUESTS_PER_SECOND: %v", err)
+		}
+	}
+
+	ytOpts := []youtube.Option{
+		youtube.WithQuotaTracker(youtube.NewQuotaTracker(store, quotaBudget)),
+		youtube.WithRateLimit(requestsPerSecond, max(1, int(requestsPerSecond))),
+	}
+	// Dry run selalu mengambil data lengkap; respons 304 tidak memberi apa pun untuk dicetak.
+	if !*dryRun {
+		ytOpts = append(ytOpts, youtube.WithETagCache(store))
+	}
+	if baseURL := os.Getenv("YOUTUBE_API_BASE_URL"); baseURL != "" {
+		log.Printf("Using YouTube API base URL %s", baseURL)
+		ytOpts = append(ytOpts, youtube.WithBaseURL(baseURL))
+	}
+	if cassettePath != "" {
+		if cassetteMode == "" {
+			cassetteMode = youtube.CassetteRecord
+		}
+		recorder, err := youtube.NewRecorder(cassettePath, cassetteMode, nil)
+		if err != nil {
+			log.Fatalf("Could not open YouTube cassette: %v", err)
+		}
+		log.Printf("YouTube cassette %s in %s mode", cassettePath, cassetteMode)
+		ytOpts = append(ytOpts, youtube.WithHTTPClient(&http.Client{Transport: recorder, Timeout: 10 * time.Second}))
+	}
+
+	ytClient := youtube.NewClient(apiKeys, ytOpts...)
+	log.Printf("Using %d YouTube API key(s)", len(apiKeys))
 
 	app := AppConfig{
 		Store:         store,
 		YouTubeClient: ytClient,
+		RunTimeout:    runTimeout,
+
+		MinEpisodeDuration: minEpisodeDuration,
+		FullSyncInterval:   fullSyncInterval,
+
+		HolderID: newHolderID(),
+		LeaseTTL: leaseTTL,
+
+		ChannelConcurrency:    channelConcurrency,
+		PlaylistConcurrency:   playlistConcurrency,
+		VideoBatchConcurrency: videoBatchConcurrency,
+
+		MatchAutoThreshold:   matchAutoThreshold,
+		MatchReviewThreshold: matchReviewThreshold,
+
+		SnapshotKeepHourly: snapshotKeepHourly,
+		SnapshotKeepDaily:  snapshotKeepDaily,
+		ETagRetention:      etagRetention,
+
+		OnlyChannel:  *onlyChannel,
+		OnlyPlaylist: *onlyPlaylist,
+		Plan:         plan,
+	}
+	if *dryRun {
+		// Satu per satu agar rencana tercetak berurutan, dan selalu penuh agar semua item terlihat.
+		app.FullSyncInterval = 0
+		app.ChannelConcurrency, app.PlaylistConcurrency, app.VideoBatchConcurrency = 1, 1, 1
+	}
+
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.Printf("WARN: Could not close database pool: %v", err)
+		}
+	}()
+
+	// sigCtx selesai saat menerima SIGINT/SIGTERM. Run yang sedang berjalan
+	// memakai ctx terpisah yang baru dibatalkan setelah shutdownTimeout, jadi
+	// run masih sempat selesai; kalau tidak, playlist yang sudah tuntas tetap
+	// tersimpan di playlist_sync_state dan sisanya dilanjutkan run berikutnya.
+	sigCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	app.stopping = sigCtx.Done()
+	ctx, cancelRuns := context.WithCancelCause(context.Background())
+	defer cancelRuns(nil)
+	go func() {
+		select {
+		case <-sigCtx.Done():
+		case <-ctx.Done():
+			return
+		}
+		// Sinyal kedua langsung menghentikan proses seperti biasa.
+		stop()
+		log.Printf("Shutdown signal received, waiting up to %s for running tasks to finish...", shutdownTimeout)
+		timer := time.NewTimer(shutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			log.Println("WARN: Shutdown timeout reached, cancelling the running tasks")
+			cancelRuns(errShuttingDown)
+		case <-ctx.Done():
+		}
+	}()
+
+	if app.OnlyPlaylist != "" {
+		playlist, err := ytClient.GetPlaylist(ctx, app.OnlyPlaylist)
+		if err != nil {
+			return fmt.Errorf("could not look up playlist %s: %w", app.OnlyPlaylist, err)
+		}
+		if app.OnlyChannel != "" && app.OnlyChannel != playlist.Snippet.ChannelID {
+			return fmt.Errorf("playlist %s belongs to channel %s, not %s", app.OnlyPlaylist, playlist.Snippet.ChannelID, app.OnlyChannel)
+		}
+		app.OnlyChannel = playlist.Snippet.ChannelID
+	}
+
+	if *once {
+		// Hanya discovery dan reconcile yang mengenal -channel dan -playlist dan
+		// aman untuk dry run; views membaca statistik semua episode (memakan
+		// quota) dan avatars menulis file gambar.
+		syncOnly := *onlyChannel != "" || *onlyPlaylist != "" || *dryRun
+		var tasks []task
+		if *taskName != "" {
+			t, ok := app.findTask(*taskName)
+			if !ok {
+				return fmt.Errorf("unknown task %q", *taskName)
+			}
+			if syncOnly && t.lease != syncLeaseName {
+				return fmt.Errorf("task %s cannot be combined with -channel, -playlist or -dry-run", t.name)
+			}
+			tasks = append(tasks, t)
+		} else if syncOnly {
+			t, _ := app.findTask(models.TaskDiscovery)
+			tasks = append(tasks, t)
+		} else {
+			for _, t := range app.tasks() {
+				if t.onStart {
+					tasks = append(tasks, t)
+				}
+			}
+		}
+		for _, t := range tasks {
+			err := app.runTask(ctx, t)
+			// Replika lain sedang menjalankan task yang sama; bukan kegagalan.
+			if errors.Is(err, errLeaseBusy) {
+				log.Printf("Task %s skipped: %v", t.name, err)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("task %s failed: %w", t.name, err)
+			}
+		}
+		return nil
+	}
+
+	// Metrik expvar (lease, dll.) bisa dibaca di http://<addr>/debug/vars.
+	var metricsSrv *http.Server
+	if addr := os.Getenv("WORKER_METRICS_ADDR"); addr != "" {
+		metricsSrv = &http.Server{Addr: addr}
+		go func() {
+			log.Printf("Serving worker metrics on %s/debug/vars", addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("ERROR: Metrics server stopped: %v", err)
+			}
+		}()
 	}
 
 	log.Println("Starting cron job scheduler...")
 	c := cron.New(cron.WithSeconds())
+	if err := app.scheduleTasks(ctx, c); err != nil {
+		return fmt.Errorf("could not schedule tasks: %w", err)
+	}
 
-	_, err = c.AddFunc("0 0 */12 * * *", func() {
-		log.Println("--- Running Worker ---")
-		app.runWorker()
-		log.Println("--- Worker Finished ---")
-	})
+	log.Println("--- Running startup tasks ---")
+	for _, t := range app.tasks() {
+		if t.onStart && sigCtx.Err() == nil {
+			app.runTask(ctx, t)
+		}
+	}
+	log.Println("--- Startup tasks finished ---")
+
+	if sigCtx.Err() == nil {
+		c.Start()
+		<-sigCtx.Done()
+	}
+	log.Println("Stopping scheduler...")
+	// Stop tidak menjadwalkan job baru dan mengembalikan ctx yang selesai
+	// setelah job yang sedang berjalan kembali.
+	<-c.Stop().Done()
+	cancelRuns(nil)
+
+	if metricsSrv != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		metricsSrv.Shutdown(shutdownCtx)
+	}
+	log.Println("Worker stopped")
+	return nil
+}
+
+// runSync menjalankan task discovery atau reconcile untuk semua channel target.
+// Discovery hanya membaca item baru setiap playlist (dengan sinkronisasi penuh
+// bila FullSyncInterval terlewati), sedangkan reconcile selalu membaca ulang
+// seluruh playlist. Run dibatalkan saat ctx selesai, setelah RunTimeout
+// terlewati, atau saat salah satu channel menemui error fatal.
+func (app *AppConfig) runSync(ctx context.Context, taskName string) error {
+	ctx, cancel := app.runContext(ctx)
+	defer cancel(nil)
+	forceFull := taskName == models.TaskReconcile
+
+	// Daftar channel dibaca ulang setiap run agar perubahan lewat channelctl langsung berlaku.
+	channels, err := app.targetChannels(ctx)
 	if err != nil {
-		log.Fatalf("Could not add cron job: %v", err)
+		log.Printf("ERROR: Could not load target channels: %v", err)
+		return fmt.Errorf("could not load target channels: %w", err)
+	}
+	if len(channels) == 0 {
+		log.Println("WARN: No enabled channels configured, nothing to sync")
 	}
 
-	log.Println("--- Running initial worker job ---")
-	app.runWorker()
-	log.Println("--- Initial worker job finished ---")
+	run := app.startRun(ctx, taskName)
+	ctx, units := youtube.WithUnitCounter(ctx)
+	app.backfillSeasons(ctx, &run)
+	plans := pipeline.Map(ctx, app.ChannelConcurrency, channels, func(ctx context.Context, channel models.Channel) (channelPlan, error) {
+		log.Printf("Processing channel: %s", channel.Name)
+		plan := app.planChannel(ctx, run.ID, channel)
+		if isFatalAPIError(plan.Err) {
+			cancel(plan.Err)
+		}
+		return plan, nil
+	})
+	// Pencocokan anime berjalan berurutan sesuai urutan channel (priority
+	// tertinggi lebih dulu), jadi anime baru selalu dibuat dari judul channel
+	// dengan priority tertinggi, berapa pun concurrency-nya.
+	matched := make([]channelPlan, len(plans))
+	matcher := &animeMatcher{}
+	for i, r := range plans {
+		matched[i] = r.Value
+		if r.Err != nil {
+			matched[i] = channelPlan{Channel: channels[i], Err: r.Err}
+		}
+		app.matchPlaylists(ctx, matcher, &matched[i])
+	}
+	results := pipeline.Map(ctx, app.ChannelConcurrency, matched, func(ctx context.Context, plan channelPlan) (channelResult, error) {
+		result, err := app.processChannel(ctx, run.ID, plan, forceFull)
+		if isFatalAPIError(err) {
+			cancel(err)
+		}
+		return result, err
+	})
 
-	c.Start()
-	select {}
+	var animeIDs []int
+	var stats []models.EpisodeStatistics
+	for i, r := range results {
+		name := channels[i].Name
+		if r.Err != nil {
+			log.Printf("ERROR: Channel %s: %v", name, r.Err)
+			run.ErrorCount++
+		}
+		if r.Value.Playlists > 0 {
+			log.Printf("Channel %s: %d playlists, %d synced, %d unchanged, %d pending review, %d failed, %d episodes inserted, %d updated, %d removed", name, r.Value.Playlists, r.Value.Synced, r.Value.Unchanged, r.Value.Pending, r.Value.Failed, r.Value.EpisodesInserted, r.Value.EpisodesUpdated, r.Value.EpisodesRemoved)
+		}
+		run.PlaylistsSeen += r.Value.Playlists
+		run.ErrorCount += r.Value.Failed
+		run.SyncCounts.Add(r.Value.SyncCounts)
+		animeIDs = append(animeIDs, r.Value.AnimeIDs...)
+		stats = append(stats, r.Value.Stats...)
+	}
+	app.recomputeAggregates(ctx, &run, animeIDs)
+	app.saveViewSnapshots(ctx, &run, stats)
+	run.APIUnits = units.Units()
+	return app.finishRun(ctx, &run, nil)
 }
 
-func (app *AppConfig) runWorker() {
-	for name, id := range targetChannels {
-		log.Printf("Processing channel: %s", name)
+// recomputeAggregates menghitung ulang total views, waktu update dan thumbnail
+// anime yang disentuh run dari seluruh episodenya, sekali setelah semua
+// playlist selesai. Tetap dijalankan walaupun run dibatalkan, agar episode yang
+// sudah tersimpan ikut terhitung.
+func (app *AppConfig) recomputeAggregates(ctx context.Context, run *models.SyncRun, animeIDs []int) {
+	if len(animeIDs) == 0 {
+		return
+	}
+	slices.Sort(animeIDs)
+	animeIDs = slices.Compact(animeIDs)
+	n, err := app.Store.RecomputeAnimeAggregates(context.WithoutCancel(ctx), animeIDs)
+	if err != nil {
+		log.Printf("ERROR: Could not recompute aggregates of %d animes: %v", len(animeIDs), err)
+		run.ErrorCount++
+		return
+	}
+	log.Printf("Recomputed aggregates of %d animes, %d changed", len(animeIDs), n)
+}
 
-		profilePicURL, err := app.YouTubeClient.GetChannelProfilePicture(id)
+// backfillSeasons mengisi season playlist yang tersimpan sebelum tabel seasons
+// ada, dengan aturan judul yang sama seperti sinkronisasi playlist. Playlist
+// yang masih ada di channel juga diisi oleh processPlaylist, tetapi playlist
+// yang sudah hilang dari channel atau milik channel nonaktif hanya terisi di
+// sini. Setelah sekali berhasil, query-nya tidak lagi menemukan playlist.
+func (app *AppConfig) backfillSeasons(ctx context.Context, run *models.SyncRun) {
+	playlists, err := app.Store.GetPlaylistsWithoutSeason(ctx)
+	if err != nil {
+		log.Printf("ERROR: Could not load playlists without season: %v", err)
+		run.ErrorCount++
+		return
+	}
+	for _, p := range playlists {
+		season := titles.ParseSeason(p.Title)
+		if app.Plan != nil {
+			app.Plan.print("UPDATE", "playlist", "%s %q -> %s", p.ID, p.Title, season.Label)
+		}
+		seasonID, err := app.Store.UpsertSeason(ctx, models.Season{AnimeID: *p.AnimeID, Number: season.Number, Part: season.Part, Label: season.Label})
+		if err == nil {
+			p.SeasonID = &seasonID
+			err = app.Store.UpsertPlaylist(ctx, p)
+		}
 		if err != nil {
-			log.Printf("ERROR: Could not get profile picture for channel %s: %v", name, err)
+			log.Printf("ERROR: Could not backfill season of playlist %s: %v", p.ID, err)
+			run.ErrorCount++
+			return
 		}
+	}
+	if len(playlists) > 0 {
+		log.Printf("Backfilled seasons of %d playlists", len(playlists))
+	}
+}
 
-		var localImagePath string
-		if profilePicURL != "" {
-			localImagePath = fmt.Sprintf("/img/channels/%s.jpg", id)
-			fullPath := filepath.Join("web", strings.TrimPrefix(localImagePath, "/"))
+// saveViewSnapshots mencatat snapshot views episode yang baru disinkronkan dan
+// total views anime setelah agregatnya dihitung ulang, agar tren juga mencakup
+// episode baru sebelum task views berikutnya. Seperti recomputeAggregates,
+// tetap dijalankan walaupun run dibatalkan.
+func (app *AppConfig) saveViewSnapshots(ctx context.Context, run *models.SyncRun, stats []models.EpisodeStatistics) {
+	if len(stats) == 0 {
+		return
+	}
+	if err := app.Store.SaveViewSnapshots(context.WithoutCancel(ctx), stats); err != nil {
+		log.Printf("ERROR: Could not save view snapshots of %d episodes: %v", len(stats), err)
+		run.ErrorCount++
+		return
+	}
+	log.Printf("Saved view snapshots of %d episodes", len(stats))
+}
 
-			if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-				errDownload := downloadAndSaveImage(profilePicURL, fullPath)
-				if errDownload != nil {
-					log.Printf("ERROR: Could not download image for channel %s: %v", name, errDownload)
-					localImagePath = ""
-				}
-			}
+// errShuttingDown adalah penyebab pembatalan run saat worker dimatikan.
+var errShuttingDown = errors.New("worker is shutting down")
+
+// targetChannels mengembalikan channel yang disinkronkan run ini: semua channel
+// aktif, atau hanya OnlyChannel (walaupun sedang dinonaktifkan). Pada dry run,
+// channel yang belum terdaftar juga boleh dipakai untuk mencoba channel baru.
+func (app *AppConfig) targetChannels(ctx context.Context) ([]models.Channel, error) {
+	if app.OnlyChannel == "" {
+		return app.Store.GetEnabledChannels(ctx)
+	}
+	channels, err := app.Store.ListChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range channels {
+		if ch.ID == app.OnlyChannel {
+			return []models.Channel{ch}, nil
 		}
+	}
+	if app.Plan == nil {
+		return nil, fmt.Errorf("channel %s is not configured, add it with channelctl first", app.OnlyChannel)
+	}
+	return []models.Channel{{ID: app.OnlyChannel, Name: app.OnlyChannel, Enabled: true}}, nil
+}
 
-		channelURL := "https://www.youtube.com/channel/" + id
-		err = app.Store.UpsertChannel(models.Channel{ID: id, Name: name, URL: channelURL, ProfilePictureURL: &localImagePath})
-		if err != nil {
-			log.Printf("ERROR: Could not upsert channel %s: %v", name, err)
-			continue
+// channelResult adalah ringkasan sinkronisasi satu channel.
+type channelResult struct {
+	Playlists int // Playlist relevan yang ditemukan
+	Synced    int
+	Unchanged int
+	Pending   int // Menunggu review pencocokan anime
+	Failed    int
+	models.SyncCounts
+	AnimeIDs []int                      // Anime yang playlist-nya disinkronkan, untuk dihitung ulang agregatnya
+	Stats    []models.EpisodeStatistics // Statistik episode yang tersimpan, untuk snapshot views
+}
+
+// playlistResult adalah ringkasan sinkronisasi satu playlist.
+type playlistResult struct {
+	AnimeID       int // 0 jika playlist belum tertaut ke anime
+	Unchanged     bool
+	FullSync      bool
+	PendingReview bool
+	models.SyncCounts
+	Stats []models.EpisodeStatistics // Statistik episode yang tersimpan, untuk snapshot views
+}
+
+// channelPlan adalah playlist relevan satu channel beserta hasil pencocokan
+// animenya, disiapkan sebelum episode-episodenya disinkronkan.
+type channelPlan struct {
+	Channel   models.Channel
+	StartedAt time.Time
+	Playlists []matchedPlaylist
+	APIUnits  int64 // Unit quota untuk mengambil daftar playlist
+	Err       error // Daftar playlist tidak bisa diambil
+}
+
+// matchedPlaylist adalah playlist relevan dan anime tujuannya.
+type matchedPlaylist struct {
+	youtube.PlaylistItem
+	AnimeID int
+	Pending bool  // Menunggu review pencocokan anime
+	Err     error // Anime tidak bisa dicari atau dibuat
+}
+
+// planChannel mengambil daftar playlist channel dan memilih yang relevan. Jika
+// daftar playlist tidak bisa diambil, error dicatat ke riwayat run dan disimpan
+// di Err; error itu bersifat fatal jika isFatalAPIError bernilai true.
+func (app *AppConfig) planChannel(ctx context.Context, runID int64, channel models.Channel) channelPlan {
+	plan := channelPlan{Channel: channel, StartedAt: time.Now()}
+	ctx, units := youtube.WithUnitCounter(ctx)
+
+	playlists, err := app.YouTubeClient.GetPlaylistsForChannel(ctx, channel.ID)
+	plan.APIUnits = units.Units()
+	if err != nil {
+		plan.Err = fmt.Errorf("could not get playlists: %w", err)
+		if runID != 0 {
+			app.recordChannel(ctx, runID, channel.ID, plan.StartedAt, channelResult{}, plan.APIUnits, plan.Err)
 		}
+		return plan
+	}
 
-		playlists, err := app.YouTubeClient.GetPlaylistsForChannel(id)
-		if err != nil {
-			log.Printf("ERROR: Could not get playlists for channel %s: %v", name, err)
+	log.Printf("Found %d playlists for channel %s", len(playlists), channel.Name)
+
+	for _, p := range playlists {
+		if app.OnlyPlaylist != "" && p.ID != app.OnlyPlaylist {
 			continue
 		}
+		isRelevant := isRelevantPlaylist(p.Snippet.Title, channel)
+		if app.Plan != nil {
+			action := "SKIP"
+			if isRelevant {
+				action = "SYNC"
+			}
+			app.Plan.print(action, "playlist", "%s %q -> title %q, %s, language %s", p.ID, p.Snippet.Title, titles.Extract(p.Snippet.Title), titles.ParseSeason(p.Snippet.Title).Label, playlistLanguage(p.Snippet.Title, channel))
+		}
+		if isRelevant {
+			plan.Playlists = append(plan.Playlists, matchedPlaylist{PlaylistItem: p})
+		}
+	}
+	return plan
+}
 
-		log.Printf("Found %d playlists for channel %s", len(playlists), name)
+// matchPlaylists mencari atau membuat anime untuk setiap playlist plan satu
+// per satu, sesuai urutan playlist di channel.
+func (app *AppConfig) matchPlaylists(ctx context.Context, matcher *animeMatcher, plan *channelPlan) {
+	for i := range plan.Playlists {
+		if plan.Err != nil || ctx.Err() != nil {
+			return
+		}
+		m := &plan.Playlists[i]
+		m.AnimeID, m.Pending, m.Err = app.resolveAnime(ctx, matcher, m.PlaylistItem)
+		if m.Err != nil {
+			m.Err = fmt.Errorf("could not find or create anime '%s': %w", titles.Extract(m.Snippet.Title), m.Err)
+		}
+	}
+}
 
-		for _, p := range playlists {
-			if !isRelevantPlaylist(p.Snippet.Title) {
-				continue
-			}
-			log.Printf("  -> Processing relevant playlist: %s", p.Snippet.Title)
+// processChannel menyinkronkan semua playlist relevan satu channel yang sudah
+// dicocokkan, dengan paling banyak PlaylistConcurrency playlist sekaligus.
+// forceFull membaca ulang seluruh playlist. Error yang dikembalikan bersifat
+// fatal jika isFatalAPIError bernilai true.
+func (app *AppConfig) processChannel(ctx context.Context, runID int64, plan channelPlan, forceFull bool) (result channelResult, err error) {
+	if plan.Err != nil {
+		return result, plan.Err
+	}
+	channel := plan.Channel
+	ctx, units := youtube.WithUnitCounter(ctx)
+	if runID != 0 {
+		defer func() {
+			app.recordChannel(ctx, runID, channel.ID, plan.StartedAt, result, plan.APIUnits+units.Units(), err)
+		}()
+	}
+	result.Playlists = len(plan.Playlists)
 
-			animeTitle := extractAnimeTitle(p.Snippet.Title)
-			// Perbaikan: Memanggil findOrCreateAnime sebagai method dari app
-			animeID, err := app.findOrCreateAnime(animeTitle, p.Snippet.Description)
-			if err != nil {
-				log.Printf("    ERROR: Could not find or create anime '%s': %v", animeTitle, err)
-				continue
+	// Error fatal di satu playlist menghentikan playlist lain yang belum dimulai.
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	results := pipeline.Map(ctx, app.PlaylistConcurrency, plan.Playlists, func(ctx context.Context, p matchedPlaylist) (playlistResult, error) {
+		log.Printf("  -> Processing relevant playlist: %s", p.Snippet.Title)
+		ctx, units := youtube.WithUnitCounter(ctx)
+		startedAt := time.Now()
+		res, err := app.processPlaylist(ctx, channel, p, forceFull)
+		res.APIUnits = units.Units()
+		if runID != 0 {
+			app.recordPlaylist(ctx, runID, channel.ID, p.ID, startedAt, res, err)
+		}
+		if err != nil {
+			log.Printf("    ERROR: Playlist '%s': %v", p.Snippet.Title, err)
+			if isFatalAPIError(err) {
+				cancel(err)
 			}
+		}
+		return res, err
+	})
 
-			playlistModel := models.Playlist{
-				ID:          p.ID,
-				ChannelID:   p.Snippet.ChannelID,
-				AnimeID:     &animeID,
-				Title:       p.Snippet.Title,
-				Description: &p.Snippet.Description,
-				Language:    extractLanguage(p.Snippet.Title),
-			}
-			err = app.Store.UpsertPlaylist(playlistModel)
-			if err != nil {
-				log.Printf("    ERROR: Could not upsert playlist '%s': %v", p.Snippet.Title, err)
-				continue
-			}
+	for _, r := range results {
+		if r.Value.AnimeID != 0 {
+			result.AnimeIDs = append(result.AnimeIDs, r.Value.AnimeID)
+		}
+		switch {
+		case r.Err != nil:
+			result.Failed++
+		case r.Value.PendingReview:
+			result.Pending++
+		case r.Value.Unchanged:
+			result.Unchanged++
+		default:
+			result.Synced++
+		}
+		result.SyncCounts.Add(r.Value.SyncCounts)
+		result.Stats = append(result.Stats, r.Value.Stats...)
+	}
+	// Unit quota channel juga mencakup daftar playlist.
+	result.APIUnits = plan.APIUnits + units.Units()
+	if cause := context.Cause(ctx); cause != nil && isFatalAPIError(cause) {
+		return result, cause
+	}
+	return result, nil
+}
 
-			videos, err := app.YouTubeClient.GetVideosForPlaylist(p.ID)
-			if err != nil {
-				log.Printf("    ERROR: Could not get videos for playlist '%s': %v", p.Snippet.Title, err)
-				continue
-			}
-			if len(videos) == 0 {
-				continue
-			}
+// processPlaylist menyinkronkan episode satu playlist.
+//
+// Sinkronisasi penuh mengambil seluruh playlist tanpa ETag tersimpan dan
+// menandai episode yang hilang. Di antara sinkronisasi penuh (lihat
+// FullSyncInterval), playlist hanya dibaca mulai dari halaman terakhir yang
+// tersimpan dan hanya item setelah watermark publishedAt yang diproses.
+// forceFull selalu membaca seluruh playlist.
+// Agregat anime (total views, last_updated, thumbnail) dihitung ulang sekali
+// setelah semua playlist selesai, lihat recomputeAggregates.
+func (app *AppConfig) processPlaylist(ctx context.Context, channel models.Channel, p matchedPlaylist, forceFull bool) (playlistResult, error) {
+	var result playlistResult
 
-			var videoIDs []string
-			for _, v := range videos {
-				videoIDs = append(videoIDs, v.Snippet.ResourceID.VideoID)
-			}
+	if p.Err != nil {
+		return result, p.Err
+	}
+	if p.Pending {
+		log.Printf("    INFO: Playlist '%s' is waiting for an anime match review, skipping", p.Snippet.Title)
+		result.PendingReview = true
+		return result, nil
+	}
+	animeID := p.AnimeID
+	result.AnimeID = animeID
 
-			videoDetails, err := app.YouTubeClient.GetVideoDetails(videoIDs)
-			if err != nil {
-				log.Printf("    ERROR: Could not get video details for playlist '%s': %v", p.Snippet.Title, err)
-				continue
-			}
+	season := titles.ParseSeason(p.Snippet.Title)
+	seasonID, err := app.Store.UpsertSeason(ctx, models.Season{AnimeID: animeID, Number: season.Number, Part: season.Part, Label: season.Label})
+	if err != nil {
+		return result, fmt.Errorf("could not upsert season: %w", err)
+	}
 
-			viewCounts := make(map[string]int64)
-			for _, detail := range videoDetails {
-				vc, _ := strconv.ParseInt(detail.Statistics.ViewCount, 10, 64)
-				viewCounts[detail.ID] = vc
+	playlistModel := models.Playlist{
+		ID:          p.ID,
+		ChannelID:   p.Snippet.ChannelID,
+		AnimeID:     &animeID,
+		SeasonID:    &seasonID,
+		Title:       p.Snippet.Title,
+		Description: &p.Snippet.Description,
+		Language:    playlistLanguage(p.Snippet.Title, channel),
+	}
+	err = app.Store.UpsertPlaylist(ctx, playlistModel)
+	if err != nil {
+		return result, fmt.Errorf("could not upsert playlist: %w", err)
+	}
+
+	state, err := app.Store.GetPlaylistSyncState(ctx, p.ID)
+	if err != nil {
+		return result, fmt.Errorf("could not load sync state: %w", err)
+	}
+
+	full := forceFull || app.needsFullSync(state)
+	var page youtube.PlaylistItems
+	var videos []youtube.VideoItem
+	if !full {
+		page, err = app.YouTubeClient.GetVideosForPlaylistFrom(ctx, p.ID, state.LastPageToken)
+		switch {
+		case errors.Is(err, youtube.ErrNotModified):
+			log.Printf("    INFO: Playlist '%s' has no new items, skipping", p.Snippet.Title)
+			result.Unchanged = true
+			return result, nil
+		case isFatalAPIError(err):
+			return result, fmt.Errorf("could not get videos: %w", err)
+		case err != nil:
+			log.Printf("    WARN: Incremental sync of playlist '%s' failed (%v), running a full sync", p.Snippet.Title, err)
+			full = true
+		default:
+			videos = itemsAfterWatermark(page.Items, state)
+			// Jumlah item yang tidak cocok berarti ada video yang dihapus atau urutan berubah.
+			if page.TotalResults != state.ItemCount+len(videos) {
+				log.Printf("    INFO: Playlist '%s' changed beyond appended items, running a full sync", p.Snippet.Title)
+				full = true
 			}
+		}
+	}
 
-			var currentTotalViews int64 = 0
-			var latestEpisodeTime *time.Time
-			var firstEpisodeThumbnailURL *string
-			var earliestDate *time.Time
-
-			for _, v := range videos {
-				epNum := extractEpisodeNumber(v.Snippet.Title)
-				thumbURL := v.Snippet.Thumbnails.High.URL
-
-				episodeModel := models.Episode{
-					VideoID:       v.Snippet.ResourceID.VideoID,
-					PlaylistID:    p.ID,
-					Title:         v.Snippet.Title,
-					EpisodeNumber: epNum,
-					PublishedAt:   &v.Snippet.PublishedAt,
-					ThumbnailURL:  &thumbURL,
-					ViewCount:     viewCounts[v.Snippet.ResourceID.VideoID],
-				}
-				err := app.Store.UpsertEpisode(episodeModel)
-				if err != nil {
-					log.Printf("      ERROR: Could not upsert episode '%s': %v", v.Snippet.Title, err)
-				}
+	result.FullSync = full
+	if full {
+		// 304 di halaman pertama tidak menjamin halaman lain dan detail video
+		// (privasi, region, durasi) tidak berubah, jadi ETag tidak dipakai.
+		page, err = app.YouTubeClient.GetVideosForPlaylistFrom(youtube.WithoutETags(ctx), p.ID, "")
+		if errors.Is(err, youtube.ErrPlaylistNotFound) {
+			log.Printf("    WARN: Playlist '%s' no longer exists, marking its episodes unavailable", p.Snippet.Title)
+			result.EpisodesRemoved, err = app.reconcilePlaylist(ctx, p.ID, nil)
+			return result, err
+		}
+		if err != nil {
+			return result, fmt.Errorf("could not get videos: %w", err)
+		}
+		videos = page.Items
+	}
 
-				currentTotalViews += episodeModel.ViewCount
+	if full && len(videos) == 0 {
+		result.EpisodesRemoved, err = app.reconcilePlaylist(ctx, p.ID, nil)
+		return result, err
+	}
 
-				if latestEpisodeTime == nil || v.Snippet.PublishedAt.After(*latestEpisodeTime) {
-					latestEpisodeTime = &v.Snippet.PublishedAt
-				}
+	var videoIDs []string
+	for _, v := range videos {
+		videoIDs = append(videoIDs, v.Snippet.ResourceID.VideoID)
+	}
 
-				if earliestDate == nil || v.Snippet.PublishedAt.Before(*earliestDate) {
-					earliestDate = &v.Snippet.PublishedAt
-					firstEpisodeThumbnailURL = &thumbURL
-				}
-			}
+	details, err := app.fetchVideoDetails(ctx, videoIDs)
+	if err != nil {
+		app.invalidatePlaylist(ctx, p.ID, state)
+		return result, fmt.Errorf("could not get video details: %w", err)
+	}
 
-			oldTotalViews, err := app.Store.GetAnimeViewData(animeID)
-			if err != nil {
-				log.Printf("    WARN: Could not get old view data for anime ID %d: %v", animeID, err)
+	var presentVideoIDs []string
+	failed := 0
+
+	for _, v := range videos {
+		videoID := v.Snippet.ResourceID.VideoID
+		if reason := unavailableReason(details, videoID); reason != "" {
+			if err := app.Store.MarkEpisodeUnavailable(ctx, videoID, reason); err != nil {
+				log.Printf("      ERROR: Could not mark episode %s unavailable: %v", videoID, err)
+				failed++
 			}
+			continue
+		}
+		epNum := extractEpisodeNumber(v.Snippet.Title)
+		thumbURL := v.Snippet.Thumbnails.High.URL
 
-			weeklyIncrease := currentTotalViews - oldTotalViews
+		episodeModel := models.Episode{
+			VideoID:       videoID,
+			PlaylistID:    p.ID,
+			Title:         v.Snippet.Title,
+			EpisodeNumber: epNum,
+			PublishedAt:   &v.Snippet.PublishedAt,
+			ThumbnailURL:  &thumbURL,
+		}
+		applyVideoDetails(&episodeModel, details[videoID])
 
-			err = app.Store.UpdateAnimeViewData(animeID, currentTotalViews, weeklyIncrease)
-			if err != nil {
-				log.Printf("    ERROR: Could not update view data for anime ID %d: %v", animeID, err)
-			}
+		// Short dan PV yang ikut masuk playlist episode tidak dihitung sebagai
+		// episode. Karena tidak ikut presentVideoIDs, short yang tersimpan
+		// sebelum filter ini ada ditandai tidak tersedia saat sinkronisasi penuh.
+		if episodeModel.DurationSeconds != nil && time.Duration(*episodeModel.DurationSeconds)*time.Second < app.MinEpisodeDuration {
+			continue
+		}
+		presentVideoIDs = append(presentVideoIDs, videoID)
+		inserted, err := app.Store.UpsertEpisode(ctx, episodeModel)
+		switch {
+		case err != nil:
+			log.Printf("      ERROR: Could not upsert episode '%s': %v", v.Snippet.Title, err)
+			failed++
+		case inserted:
+			result.EpisodesInserted++
+		default:
+			result.EpisodesUpdated++
+		}
+		if err == nil {
+			result.Stats = append(result.Stats, models.EpisodeStatistics{VideoID: videoID, ViewCount: episodeModel.ViewCount, LikeCount: episodeModel.LikeCount, CommentCount: episodeModel.CommentCount})
+		}
+	}
 
-			if latestEpisodeTime != nil {
-				err := app.Store.UpdateAnimeLastUpdated(animeID, *latestEpisodeTime)
-				if err != nil {
-					log.Printf("    ERROR: Could not update last_updated for anime ID %d: %v", animeID, err)
-				}
-			}
-			if firstEpisodeThumbnailURL != nil {
-				err := app.Store.UpdateAnimeThumbnailURL(animeID, *firstEpisodeThumbnailURL)
-				if err != nil {
-					log.Printf("    WARN: Could not update thumbnail for anime ID %d: %v", animeID, err)
-				}
-			}
+	if full {
+		result.EpisodesRemoved, err = app.reconcilePlaylist(ctx, p.ID, presentVideoIDs)
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		app.invalidatePlaylist(ctx, p.ID, state)
+	}
+
+	if failed > 0 {
+		// Status tidak disimpan agar item yang gagal diproses ulang di run berikutnya.
+		return result, fmt.Errorf("%d episode(s) could not be saved", failed)
+	}
+	return result, app.saveSyncState(ctx, nextSyncState(p.ID, state, page, videos, full))
+}
 
-			time.Sleep(2 * time.Second)
+// recordChannel menyimpan ringkasan channel ke riwayat run. Kegagalan hanya dicatat di log.
+func (app *AppConfig) recordChannel(ctx context.Context, runID int64, channelID string, startedAt time.Time, result channelResult, units int64, err error) {
+	record := models.SyncRunChannel{
+		RunID:         runID,
+		ChannelID:     channelID,
+		StartedAt:     startedAt,
+		FinishedAt:    time.Now(),
+		PlaylistsSeen: result.Playlists,
+		SyncCounts:    result.SyncCounts,
+		ErrorCount:    result.Failed,
+	}
+	record.APIUnits = units
+	if err != nil {
+		msg := err.Error()
+		record.Error = &msg
+		record.ErrorCount++
+	}
+	if err := app.Store.SaveSyncRunChannel(context.WithoutCancel(ctx), record); err != nil {
+		log.Printf("WARN: Could not record channel %s for sync run %d: %v", channelID, runID, err)
+	}
+}
+
+// recordPlaylist menyimpan hasil playlist ke riwayat run. Kegagalan hanya dicatat di log.
+func (app *AppConfig) recordPlaylist(ctx context.Context, runID int64, channelID, playlistID string, startedAt time.Time, result playlistResult, err error) {
+	record := models.SyncRunPlaylist{
+		RunID:      runID,
+		PlaylistID: playlistID,
+		ChannelID:  channelID,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Status:     models.SyncSynced,
+		FullSync:   result.FullSync,
+		SyncCounts: result.SyncCounts,
+	}
+	switch {
+	case err != nil:
+		record.Status = models.SyncFailed
+		msg := err.Error()
+		record.Error = &msg
+	case result.PendingReview:
+		record.Status = models.SyncPendingReview
+	case result.Unchanged:
+		record.Status = models.SyncUnchanged
+	}
+	if err := app.Store.SaveSyncRunPlaylist(context.WithoutCancel(ctx), record); err != nil {
+		log.Printf("WARN: Could not record playlist %s for sync run %d: %v", playlistID, runID, err)
+	}
+}
+
+// needsFullSync melaporkan apakah playlist harus dibaca ulang seluruhnya.
+func (app *AppConfig) needsFullSync(state *models.PlaylistSyncState) bool {
+	if state == nil || state.LastFullSyncAt == nil || app.FullSyncInterval <= 0 {
+		return true
+	}
+	return time.Since(*state.LastFullSyncAt) >= app.FullSyncInterval
+}
+
+// itemsAfterWatermark mengembalikan item yang masuk playlist setelah watermark state.
+func itemsAfterWatermark(items []youtube.VideoItem, state *models.PlaylistSyncState) []youtube.VideoItem {
+	var fresh []youtube.VideoItem
+	for _, v := range items {
+		if state.LastVideoID != nil && v.Snippet.ResourceID.VideoID == *state.LastVideoID {
+			continue
+		}
+		if state.LastPublishedAt == nil || v.Snippet.PublishedAt.After(*state.LastPublishedAt) {
+			fresh = append(fresh, v)
 		}
 	}
+	return fresh
 }
 
-func downloadAndSaveImage(url string, filePath string) error {
+// nextSyncState membentuk status sinkronisasi setelah playlist berhasil diproses.
+func nextSyncState(playlistID string, prev *models.PlaylistSyncState, page youtube.PlaylistItems, processed []youtube.VideoItem, full bool) models.PlaylistSyncState {
+	now := time.Now()
+	state := models.PlaylistSyncState{PlaylistID: playlistID}
+	if prev != nil {
+		state = *prev
+	}
+	state.LastPageToken = page.LastPageToken
+	state.ItemCount = page.TotalResults
+	state.LastSyncedAt = now
+	if full {
+		state.LastFullSyncAt = &now
+	}
+	for _, v := range processed {
+		if state.LastPublishedAt == nil || v.Snippet.PublishedAt.After(*state.LastPublishedAt) {
+			publishedAt := v.Snippet.PublishedAt
+			videoID := v.Snippet.ResourceID.VideoID
+			state.LastPublishedAt = &publishedAt
+			state.LastVideoID = &videoID
+		}
+	}
+	return state
+}
+
+// saveSyncState menyimpan status sinkronisasi playlist.
+func (app *AppConfig) saveSyncState(ctx context.Context, state models.PlaylistSyncState) error {
+	if err := app.Store.SavePlaylistSyncState(ctx, state); err != nil {
+		return fmt.Errorf("could not save sync state: %w", err)
+	}
+	return nil
+}
+
+// videoBatchSize adalah jumlah ID maksimum per panggilan videos.list.
+const videoBatchSize = 50
+
+// fetchVideoDetails mengambil detail video dalam batch berisi videoBatchSize ID,
+// dengan paling banyak VideoBatchConcurrency batch sekaligus.
+func (app *AppConfig) fetchVideoDetails(ctx context.Context, videoIDs []string) (map[string]youtube.VideoDetailItem, error) {
+	var batches [][]string
+	for i := 0; i < len(videoIDs); i += videoBatchSize {
+		batches = append(batches, videoIDs[i:min(i+videoBatchSize, len(videoIDs))])
+	}
+
+	details := make(map[string]youtube.VideoDetailItem, len(videoIDs))
+	results := pipeline.Map(ctx, app.VideoBatchConcurrency, batches, app.YouTubeClient.GetVideoDetails)
+	if errs := pipeline.Errors(results); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	for _, r := range results {
+		for _, detail := range r.Value {
+			details[detail.ID] = detail
+		}
+	}
+	return details, nil
+}
+
+// unavailableReason mengembalikan alasan video tidak bisa ditonton lagi, atau
+// string kosong jika video masih tersedia. Video yang dihapus tetap muncul di
+// playlistItems sebagai "Deleted video" tetapi tidak dikembalikan oleh videos.list.
+func unavailableReason(details map[string]youtube.VideoDetailItem, videoID string) string {
+	detail, ok := details[videoID]
+	if !ok {
+		return models.EpisodeRemoved
+	}
+	if detail.Status.PrivacyStatus == "private" {
+		return models.EpisodePrivate
+	}
+	return ""
+}
+
+// reconcilePlaylist menandai episode tersimpan yang sudah tidak ada di playlist
+// sebagai tidak tersedia dan mengembalikan jumlah episode yang baru ditandai.
+func (app *AppConfig) reconcilePlaylist(ctx context.Context, playlistID string, presentVideoIDs []string) (int, error) {
+	n, err := app.Store.MarkMissingEpisodesUnavailable(ctx, playlistID, presentVideoIDs)
+	if err != nil {
+		return 0, fmt.Errorf("could not reconcile episodes: %w", err)
+	}
+	if n > 0 {
+		log.Printf("    INFO: Marked %d episode(s) of playlist %s unavailable", n, playlistID)
+	}
+	return int(n), nil
+}
+
+// applyVideoDetails menyalin statistik, durasi, status dan batasan region dari
+// respons videos.list ke episode.
+func applyVideoDetails(episode *models.Episode, detail youtube.VideoDetailItem) {
+	if detail.ID == "" {
+		return
+	}
+	episode.ViewCount, _ = strconv.ParseInt(detail.Statistics.ViewCount, 10, 64)
+	episode.LikeCount, _ = strconv.ParseInt(detail.Statistics.LikeCount, 10, 64)
+	episode.CommentCount, _ = strconv.ParseInt(detail.Statistics.CommentCount, 10, 64)
+
+	if d, err := youtube.ParseDuration(detail.ContentDetails.Duration); err == nil {
+		seconds := int(d.Seconds())
+		episode.DurationSeconds = &seconds
+	}
+	if detail.Status.PrivacyStatus != "" {
+		privacy := detail.Status.PrivacyStatus
+		embeddable := detail.Status.Embeddable
+		episode.PrivacyStatus = &privacy
+		episode.Embeddable = &embeddable
+	}
+	if rr := detail.ContentDetails.RegionRestriction; rr != nil {
+		if len(rr.Allowed) > 0 {
+			allowed := strings.Join(rr.Allowed, ",")
+			episode.RegionAllowed = &allowed
+		}
+		if len(rr.Blocked) > 0 {
+			blocked := strings.Join(rr.Blocked, ",")
+			episode.RegionBlocked = &blocked
+		}
+	}
+}
+
+// envInt membaca bilangan bulat positif dari environment variable name, atau def jika kosong.
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		log.Fatalf("Invalid %s: must be a positive integer", name)
+	}
+	return n
+}
+
+// envFraction membaca bilangan antara 0 dan 1 dari environment variable name, atau def jika kosong.
+func envFraction(name string, def float64) float64 {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 || f > 1 {
+		log.Fatalf("Invalid %s: must be a number between 0 and 1", name)
+	}
+	return f
+}
+
+// invalidatePlaylist membuang ETag playlist yang gagal diproses, agar run
+// berikutnya tidak melewatinya karena respons 304. state adalah status yang
+// tersimpan sebelum run ini: karena status tidak disimpan saat gagal, run
+// berikutnya mulai lagi dari halaman yang sama.
+func (app *AppConfig) invalidatePlaylist(ctx context.Context, playlistID string, state *models.PlaylistSyncState) {
+	var pageToken string
+	if state != nil {
+		pageToken = state.LastPageToken
+	}
+	if err := app.YouTubeClient.InvalidatePlaylist(context.WithoutCancel(ctx), playlistID, pageToken); err != nil {
+		log.Printf("    WARN: Could not invalidate ETag for playlist %s: %v", playlistID, err)
+	}
+}
+
+// isFatalAPIError melaporkan apakah error dari YouTube membuat sisa run tidak ada gunanya,
+// misalnya quota harian sudah habis, API key ditolak, atau run sudah dibatalkan.
+func isFatalAPIError(err error) bool {
+	return errors.Is(err, youtube.ErrQuotaExceeded) ||
+		errors.Is(err, youtube.ErrKeyInvalid) ||
+		errors.Is(err, youtube.ErrBudgetExhausted) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+func downloadAndSaveImage(ctx context.Context, url string, filePath string) error {
 	dir := filepath.Dir(filePath)
 	// Buat semua direktori perantara jika belum ada
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("could not create directory %s: %w", dir, err)
 	}
 
-	response, err := http.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -263,8 +1169,8 @@ func downloadAndSaveImage(url string, filePath string) error {
 }
 
 // Perbaikan: Mengubah findOrCreateAnime menjadi method dari *AppConfig
-func (app *AppConfig) findOrCreateAnime(title, synopsis string) (int, error) {
-	existingAnime, err := app.Store.FindAnimeByTitle(title)
+func (app *AppConfig) findOrCreateAnime(ctx context.Context, title, synopsis string) (int, error) {
+	existingAnime, err := app.Store.FindAnimeByTitle(ctx, title)
 	if err != nil {
 		return 0, err
 	}
@@ -276,17 +1182,157 @@ func (app *AppConfig) findOrCreateAnime(title, synopsis string) (int, error) {
 		Title:    title,
 		Synopsis: &synopsis,
 	}
-	return app.Store.UpsertAnime(newAnime)
+	return app.Store.UpsertAnime(ctx, newAnime)
 }
 
-func isRelevantPlaylist(title string) bool {
+// animeMatcher menyimpan snapshot judul dan alias semua anime untuk
+// pencocokan satu run. Snapshot diambil sekali, diurutkan menurut anime_id lalu
+// judul, dan anime yang dibuat selama run ditambahkan ke dalamnya, jadi kandidat
+// dengan skor sama selalu jatuh ke anime tertua apa pun urutan baris dari
+// database.
+type animeMatcher struct {
+	candidates []titles.Candidate
+	loaded     bool
+}
+
+// load mengambil snapshot judul anime jika belum diambil.
+func (m *animeMatcher) load(ctx context.Context, store database.Store) error {
+	if m.loaded {
+		return nil
+	}
+	animeTitles, err := store.ListAnimeTitles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, a := range animeTitles {
+		m.add(a.ID, a.Title)
+	}
+	m.loaded = true
+	return nil
+}
+
+// add memasukkan judul anime ke snapshot sesuai urutannya.
+func (m *animeMatcher) add(id int, title string) {
+	c := titles.Candidate{ID: id, Title: title}
+	i, found := slices.BinarySearchFunc(m.candidates, c, func(a, b titles.Candidate) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), strings.Compare(a.Title, b.Title))
+	})
+	if !found {
+		m.candidates = slices.Insert(m.candidates, i, c)
+	}
+}
+
+// resolveAnime menentukan anime untuk sebuah playlist. Playlist yang dikunci
+// admin (merge atau split) selalu tetap di anime-nya, playlist lain yang sudah
+// tertaut ke anime tetap di anime itu selama judulnya tidak berubah, dan
+// keputusan review selalu diikuti. Selain itu judul playlist dicocokkan dengan
+// judul dan alias semua anime setelah dinormalisasi (lihat package titles):
+// skor minimal MatchAutoThreshold memakai anime tersebut, skor minimal
+// MatchReviewThreshold memasukkan playlist ke antrean review (pending bernilai
+// true), dan sisanya membuat anime baru.
+//
+// resolveAnime tidak aman dipanggil bersamaan; runSync memanggilnya satu per
+// satu sesuai priority channel.
+func (app *AppConfig) resolveAnime(ctx context.Context, matcher *animeMatcher, p youtube.PlaylistItem) (animeID int, pending bool, err error) {
+	existing, err := app.Store.GetPlaylist(ctx, p.ID)
+	if err != nil {
+		return 0, false, err
+	}
+	if existing != nil && existing.AnimeID != nil && (existing.AnimeLocked || existing.Title == p.Snippet.Title) {
+		return *existing.AnimeID, false, nil
+	}
+	if err := matcher.load(ctx, app.Store); err != nil {
+		return 0, false, err
+	}
+
+	animeTitle := titles.Extract(p.Snippet.Title)
+	review, err := app.Store.GetMatchReviewForPlaylist(ctx, p.ID)
+	if err != nil {
+		return 0, false, err
+	}
+	if review != nil {
+		switch {
+		case review.Status == models.MatchPending:
+			return 0, true, nil
+		case review.Status == models.MatchAccepted && review.ResolvedAnimeID != nil:
+			return *review.ResolvedAnimeID, false, nil
+		case review.Status == models.MatchRejected:
+			id, err := app.createRejectedAnime(ctx, matcher, p, animeTitle, review.CandidateAnimeID)
+			return id, false, err
+		}
+	}
+
+	best, ok := titles.BestMatch(animeTitle, matcher.candidates)
+	switch {
+	case ok && best.Score >= app.MatchAutoThreshold:
+		if best.Title != animeTitle {
+			log.Printf("    INFO: Matched '%s' to anime '%s' (score %.2f)", animeTitle, best.Title, best.Score)
+		}
+		return best.ID, false, nil
+	case ok && best.Score >= app.MatchReviewThreshold && review == nil:
+		log.Printf("    INFO: '%s' looks like anime '%s' (score %.2f), queued for review", animeTitle, best.Title, best.Score)
+		err := app.Store.CreateMatchReview(ctx, models.AnimeMatchReview{
+			PlaylistID:       p.ID,
+			ChannelID:        p.Snippet.ChannelID,
+			PlaylistTitle:    p.Snippet.Title,
+			ExtractedTitle:   animeTitle,
+			CandidateAnimeID: &best.ID,
+			CandidateTitle:   &best.Title,
+			Score:            best.Score,
+		})
+		return 0, err == nil, err
+	}
+	id, err := app.findOrCreateAnime(ctx, animeTitle, p.Snippet.Description)
+	if err != nil {
+		return 0, false, err
+	}
+	matcher.add(id, animeTitle)
+	return id, false, nil
+}
+
+// createRejectedAnime membuat anime untuk playlist yang kandidat anime-nya
+// ditolak admin. Jika judul hasil ekstraksi sudah menjadi judul atau alias
+// anime yang ditolak itu, anime baru diberi judul berakhiran nama channel agar
+// playlist tidak kembali ke anime yang ditolak.
+func (app *AppConfig) createRejectedAnime(ctx context.Context, matcher *animeMatcher, p youtube.PlaylistItem, animeTitle string, rejectedID *int) (int, error) {
+	id, err := app.findOrCreateAnime(ctx, animeTitle, p.Snippet.Description)
+	if err != nil {
+		return 0, err
+	}
+	if rejectedID != nil && id == *rejectedID {
+		animeTitle = fmt.Sprintf("%s (%s)", animeTitle, cmp.Or(p.Snippet.ChannelTitle, p.Snippet.ChannelID))
+		id, err = app.findOrCreateAnime(ctx, animeTitle, p.Snippet.Description)
+		if err != nil {
+			return 0, err
+		}
+		if id == *rejectedID {
+			return 0, fmt.Errorf("anime '%s' was rejected for this playlist, split it manually", animeTitle)
+		}
+	}
+	matcher.add(id, animeTitle)
+	return id, nil
+}
+
+// isRelevantPlaylist memeriksa apakah playlist berisi episode. Kata kunci
+// playlist_exclude channel ditambahkan ke daftar kata kunci yang ditolak, dan
+// jika playlist_include diisi, judul harus mengandung salah satunya.
+func isRelevantPlaylist(title string, channel models.Channel) bool {
 	lowerTitle := strings.ToLower(title)
 	irrelevantKeywords := []string{"trailer", "pv", "ost", "theme song", "clip", "teaser"}
+	irrelevantKeywords = append(irrelevantKeywords, channel.ExcludeKeywords()...)
 	for _, keyword := range irrelevantKeywords {
 		if strings.Contains(lowerTitle, keyword) {
 			return false
 		}
 	}
+	if include := channel.IncludeKeywords(); len(include) > 0 {
+		for _, keyword := range include {
+			if strings.Contains(lowerTitle, keyword) {
+				return true
+			}
+		}
+		return false
+	}
 	relevantKeywords := []string{"episode", "full", "season", "s1", "s2", "s3", "s4"}
 	for _, keyword := range relevantKeywords {
 		if strings.Contains(lowerTitle, keyword) {
@@ -296,12 +1342,6 @@ func isRelevantPlaylist(title string) bool {
 	return true
 }
 
-func extractAnimeTitle(playlistTitle string) string {
-	re := regexp.MustCompile(`(?i)\[.*?\]|\(.*?\)|season \d|s\d|cour \d|part \d|full episode|sub indo`)
-	title := re.ReplaceAllString(playlistTitle, "")
-	return strings.TrimSpace(title)
-}
-
 func extractEpisodeNumber(videoTitle string) *int {
 	re := regexp.MustCompile(`(?i)(?:episode|ep|#)\s*(\d{1,3})`)
 	matches := re.FindStringSubmatch(videoTitle)
@@ -314,6 +1354,15 @@ func extractEpisodeNumber(videoTitle string) *int {
 	return nil
 }
 
+// playlistLanguage menentukan bahasa subtitle playlist dari judulnya, atau
+// memakai default_language channel jika judul tidak menyebutkan bahasa.
+func playlistLanguage(title string, channel models.Channel) string {
+	if lang := extractLanguage(title); lang != "en" || channel.DefaultLanguage == nil || *channel.DefaultLanguage == "" {
+		return lang
+	}
+	return *channel.DefaultLanguage
+}
+
 func extractLanguage(title string) string {
 	lowerTitle := strings.ToLower(title)
 	indonesianKeywords := []string{"sub indo", "indonesia", "[id]"}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"alyo/internal/core/database"
+	"alyo/internal/core/models"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memStore adalah database.Store di memori untuk pengujian sinkronisasi dan
+// lease. Hanya method yang dipakai runSync dan withLease yang diimplementasikan;
+// method lain panic karena database.Store yang di-embed bernilai nil.
+type memStore struct {
+	database.Store
+
+	mu        sync.Mutex
+	channels  []models.Channel
+	animes    []models.Anime
+	seasons   []models.Season
+	playlists map[string]models.Playlist
+	episodes  map[string]models.Episode
+	states    map[string]models.PlaylistSyncState
+	reviews   []models.AnimeMatchReview
+	runs      []models.SyncRun
+	snapshots []models.EpisodeStatistics
+	responses map[string]cachedResponse
+	leases    map[string]models.Lease
+}
+
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+func newMemStore(channels ...models.Channel) *memStore {
+	return &memStore{
+		channels:  channels,
+		playlists: make(map[string]models.Playlist),
+		episodes:  make(map[string]models.Episode),
+		states:    make(map[string]models.PlaylistSyncState),
+		responses: make(map[string]cachedResponse),
+		leases:    make(map[string]models.Lease),
+	}
+}
+
+func (s *memStore) GetEnabledChannels(ctx context.Context) ([]models.Channel, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var enabled []models.Channel
+	for _, ch := range s.channels {
+		if ch.Enabled {
+			enabled = append(enabled, ch)
+		}
+	}
+	// Urutan sama dengan DBStore: priority tertinggi lebih dulu, lalu nama.
+	slices.SortStableFunc(enabled, func(a, b models.Channel) int {
+		return cmp.Or(cmp.Compare(b.Priority, a.Priority), strings.Compare(a.Name, b.Name))
+	})
+	return enabled, nil
+}
+
+func (s *memStore) CreateSyncRun(ctx context.Context, task string, startedAt time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, models.SyncRun{ID: int64(len(s.runs) + 1), Task: task, StartedAt: startedAt, Status: models.SyncRunning})
+	return int64(len(s.runs)), nil
+}
+
+func (s *memStore) FinishSyncRun(ctx context.Context, run models.SyncRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[run.ID-1] = run
+	return nil
+}
+
+func (s *memStore) SaveSyncRunChannel(ctx context.Context, channel models.SyncRunChannel) error {
+	return nil
+}
+
+func (s *memStore) SaveSyncRunPlaylist(ctx context.Context, playlist models.SyncRunPlaylist) error {
+	return nil
+}
+
+func (s *memStore) FindAnimeByTitle(ctx context.Context, title string) (*models.Anime, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.animes {
+		if a.Title == title {
+			return &a, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) UpsertAnime(ctx context.Context, anime models.Anime) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, a := range s.animes {
+		if a.Title == anime.Title {
+			s.animes[i].Synopsis = anime.Synopsis
+			return a.ID, nil
+		}
+	}
+	anime.ID = len(s.animes) + 1
+	s.animes = append(s.animes, anime)
+	return anime.ID, nil
+}
+
+func (s *memStore) ListAnimeTitles(ctx context.Context) ([]models.AnimeTitle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var animeTitles []models.AnimeTitle
+	for _, a := range s.animes {
+		animeTitles = append(animeTitles, models.AnimeTitle{ID: a.ID, Title: a.Title})
+	}
+	return animeTitles, nil
+}
+
+func (s *memStore) UpsertSeason(ctx context.Context, season models.Season) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, existing := range s.seasons {
+		if existing.AnimeID == season.AnimeID && existing.Number == season.Number && existing.Part == season.Part {
+			s.seasons[i].Label = season.Label
+			return existing.ID, nil
+		}
+	}
+	season.ID = len(s.seasons) + 1
+	s.seasons = append(s.seasons, season)
+	return season.ID, nil
+}
+
+func (s *memStore) GetPlaylist(ctx context.Context, playlistID string) (*models.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.playlists[playlistID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func (s *memStore) GetPlaylistsWithoutSeason(ctx context.Context) ([]models.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var playlists []models.Playlist
+	for _, p := range s.playlists {
+		if p.AnimeID != nil && p.SeasonID == nil {
+			playlists = append(playlists, p)
+		}
+	}
+	return playlists, nil
+}
+
+func (s *memStore) UpsertPlaylist(ctx context.Context, playlist models.Playlist) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.playlists[playlist.ID]; ok && existing.AnimeLocked {
+		playlist.AnimeID, playlist.SeasonID, playlist.AnimeLocked = existing.AnimeID, existing.SeasonID, true
+	}
+	s.playlists[playlist.ID] = playlist
+	return nil
+}
+
+func (s *memStore) GetMatchReviewForPlaylist(ctx context.Context, playlistID string) (*models.AnimeMatchReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.reviews {
+		if r.PlaylistID == playlistID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *memStore) CreateMatchReview(ctx context.Context, review models.AnimeMatchReview) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	review.ID = int64(len(s.reviews) + 1)
+	review.Status = models.MatchPending
+	s.reviews = append(s.reviews, review)
+	return nil
+}
+
+func (s *memStore) GetPlaylistSyncState(ctx context.Context, playlistID string) (*models.PlaylistSyncState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[playlistID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+func (s *memStore) SavePlaylistSyncState(ctx context.Context, state models.PlaylistSyncState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[state.PlaylistID] = state
+	return nil
+}
+
+func (s *memStore) UpsertEpisode(ctx context.Context, episode models.Episode) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.episodes[episode.VideoID]
+	s.episodes[episode.VideoID] = episode
+	return !exists, nil
+}
+
+func (s *memStore) MarkEpisodeUnavailable(ctx context.Context, videoID string, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ep, ok := s.episodes[videoID]; ok && ep.UnavailableAt == nil {
+		s.markUnavailable(ep, reason)
+	}
+	return nil
+}
+
+func (s *memStore) MarkMissingEpisodesUnavailable(ctx context.Context, playlistID string, presentVideoIDs []string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for id, ep := range s.episodes {
+		if ep.PlaylistID == playlistID && ep.UnavailableAt == nil && !slices.Contains(presentVideoIDs, id) {
+			s.markUnavailable(ep, models.EpisodeRemoved)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memStore) markUnavailable(ep models.Episode, reason string) {
+	now := time.Now()
+	ep.UnavailableAt = &now
+	ep.UnavailableReason = &reason
+	s.episodes[ep.VideoID] = ep
+}
+
+func (s *memStore) RecomputeAnimeAggregates(ctx context.Context, animeIDs []int) (int64, error) {
+	return int64(len(animeIDs)), nil
+}
+
+func (s *memStore) SaveViewSnapshots(ctx context.Context, stats []models.EpisodeStatistics) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, stats...)
+	return nil
+}
+
+func (s *memStore) GetCachedResponse(ctx context.Context, resourceKey string) (string, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.responses[resourceKey]
+	return r.etag, r.body, nil
+}
+
+func (s *memStore) SaveCachedResponse(ctx context.Context, resourceKey string, etag string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[resourceKey] = cachedResponse{etag: etag, body: body}
+	return nil
+}
+
+func (s *memStore) DeleteCachedResponse(ctx context.Context, resourceKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, resourceKey)
+	return nil
+}
+
+// AcquireLease meniru DBStore: lease diambil jika kosong, kedaluwarsa, atau
+// memang milik holder.
+func (s *memStore) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if existing, ok := s.leases[name]; ok && existing.Holder != holder && existing.ExpiresAt.After(now) {
+		return existing, false, nil
+	}
+	lease := models.Lease{Name: name, Holder: holder, AcquiredAt: now, HeartbeatAt: now, ExpiresAt: now.Add(ttl)}
+	s.leases[name] = lease
+	return lease, true, nil
+}
+
+func (s *memStore) RenewLease(ctx context.Context, name string, holder string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lease, ok := s.leases[name]
+	if !ok || lease.Holder != holder {
+		return database.ErrLeaseLost
+	}
+	lease.HeartbeatAt = time.Now()
+	lease.ExpiresAt = lease.HeartbeatAt.Add(ttl)
+	s.leases[name] = lease
+	return nil
+}
+
+func (s *memStore) ReleaseLease(ctx context.Context, name string, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lease, ok := s.leases[name]; ok && lease.Holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
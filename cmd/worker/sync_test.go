@@ -0,0 +1,551 @@
+package main
+
+import (
+	"alyo/internal/core/models"
+	"alyo/internal/titles"
+	"alyo/internal/youtube"
+	"alyo/internal/youtube/youtubetest"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// Playlist di fixture bawaan youtubetest.
+const (
+	museFrieren   = "PL3640fc13746ca440b0681793b173d1ab"
+	museMushoku   = "PL7f54c4f5c48c0c0c4b955222368a0cb3"
+	museTrailers  = "PL46403589069b8faf92a62e2d9338bfb1"
+	aniOneMushoku = "PL010c5a30bbe79d8bba9e44f1dc517610"
+	aniOneSpy     = "PL3d9c670b5a3060908147b17aec879b72"
+	asiaFrieren   = "PL22539cfbee4de33280a61f57031d7858"
+	asiaKusuriya  = "PL640d2a310f1fe4b09244a6411284fdd2" // 60 item, lebih dari satu halaman
+)
+
+func newTestStore() *memStore {
+	return newMemStore(
+		models.Channel{ID: "UCxxnxya_32jcKj4yN1_kD7A", Name: "Muse Indonesia", Enabled: true, Priority: 2},
+		models.Channel{ID: "UC0wNSTMWIL3qaorLx0jie6A", Name: "Ani-One Asia", Enabled: true, Priority: 1},
+		models.Channel{ID: "UCGbshtvS9t-8CW11W7TooQg", Name: "Muse Asia", Enabled: true},
+	)
+}
+
+func newTestApp(store *memStore, opts ...youtube.Option) *AppConfig {
+	return &AppConfig{
+		Store:                 store,
+		YouTubeClient:         youtube.NewClient([]string{"test-key"}, opts...),
+		MinEpisodeDuration:    3 * time.Minute,
+		ChannelConcurrency:    2,
+		PlaylistConcurrency:   2,
+		VideoBatchConcurrency: 2,
+		MatchAutoThreshold:    0.9,
+		MatchReviewThreshold:  0.6,
+	}
+}
+
+func TestRunSync(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	if err := newTestApp(store, youtube.WithBaseURL(srv.URL)).runSync(ctx, models.TaskDiscovery); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// Kedua playlist Mushoku Tensei mirip (skor 0,75). Muse Indonesia punya
+	// priority lebih tinggi, jadi playlist-nya yang membuat anime dan playlist
+	// Ani-One masuk antrean review.
+	if got, want := animeTitles(store), []string{"Frieren: Beyond Journey's End", "Kusuriya no Hitorigoto", "Mushoku Tensei", "Spy x Family"}; !slices.Equal(got, want) {
+		t.Errorf("animes = %q, want %q", got, want)
+	}
+	wantPlaylists := map[string]string{
+		museFrieren:  "Frieren: Beyond Journey's End",
+		asiaFrieren:  "Frieren: Beyond Journey's End",
+		museMushoku:  "Mushoku Tensei",
+		aniOneSpy:    "Spy x Family",
+		asiaKusuriya: "Kusuriya no Hitorigoto",
+	}
+	if got := playlistAnimes(store); !maps.Equal(got, wantPlaylists) {
+		t.Errorf("playlist animes = %v, want %v", got, wantPlaylists)
+	}
+	if len(store.reviews) != 1 || store.reviews[0].PlaylistID != aniOneMushoku || store.reviews[0].Status != models.MatchPending {
+		t.Errorf("match reviews = %+v, want one pending review for %s", store.reviews, aniOneMushoku)
+	}
+	if _, ok := store.playlists[museTrailers]; ok {
+		t.Errorf("trailer playlist %s was synced", museTrailers)
+	}
+	wantEpisodes := map[string]int{museFrieren: 28, asiaFrieren: 28, museMushoku: 12, aniOneSpy: 25, asiaKusuriya: 60}
+	if got := availableEpisodes(store); !maps.Equal(got, wantEpisodes) {
+		t.Errorf("available episodes = %v, want %v", got, wantEpisodes)
+	}
+	// Kusuriya dibaca dalam dua halaman, playlist lain masing-masing satu.
+	if got := srv.Requests("playlistItems"); got != 6 {
+		t.Errorf("playlistItems requests = %d, want 6", got)
+	}
+	if run := store.runs[0]; run.Status != models.SyncSucceeded || run.EpisodesInserted != 153 || run.EpisodesRemoved != 0 {
+		t.Errorf("first run = %s, %d inserted, %d removed; want succeeded, 153 inserted, 0 removed", run.Status, run.EpisodesInserted, run.EpisodesRemoved)
+	}
+	// Snapshot views episode yang baru disimpan langsung dicatat, tidak menunggu task views.
+	if got := len(store.snapshots); got != 153 {
+		t.Errorf("view snapshots after first run = %d, want 153", got)
+	}
+
+	// Run kedua: dua episode Kusuriya dikeluarkan dari playlist dan 52 episode
+	// baru ditambahkan (110 item, tiga halaman), satu video Frieren dihapus dari
+	// YouTube dan satu video Spy x Family diprivat.
+	fixtures := editFixtures(t, func(items map[string][]map[string]any) {
+		items["playlistItems"] = slices.DeleteFunc(items["playlistItems"], func(item map[string]any) bool {
+			id := videoIDOf(item)
+			return id == "bxt15mSzFJ1" || id == "voAlzBh6BED"
+		})
+		items["videos"] = slices.DeleteFunc(items["videos"], func(video map[string]any) bool {
+			return video["id"] == "bX4_k6gn6KU"
+		})
+		for _, video := range items["videos"] {
+			if video["id"] == "N_0O-s5GSp0" {
+				video["status"].(map[string]any)["privacyStatus"] = "private"
+			}
+		}
+		appendEpisodes(items, asiaKusuriya, 52)
+	})
+	srv2, err := youtubetest.NewServerFS(fixtures)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv2.Close()
+	if err := newTestApp(store, youtube.WithBaseURL(srv2.URL)).runSync(ctx, models.TaskReconcile); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	wantUnavailable := map[string]string{
+		"bxt15mSzFJ1": models.EpisodeRemoved,
+		"voAlzBh6BED": models.EpisodeRemoved,
+		"bX4_k6gn6KU": models.EpisodeRemoved,
+		"N_0O-s5GSp0": models.EpisodePrivate,
+	}
+	if got := unavailableEpisodes(store); !maps.Equal(got, wantUnavailable) {
+		t.Errorf("unavailable episodes = %v, want %v", got, wantUnavailable)
+	}
+	wantEpisodes = map[string]int{museFrieren: 27, asiaFrieren: 28, museMushoku: 12, aniOneSpy: 24, asiaKusuriya: 110}
+	if got := availableEpisodes(store); !maps.Equal(got, wantEpisodes) {
+		t.Errorf("available episodes after second run = %v, want %v", got, wantEpisodes)
+	}
+	if got := srv2.Requests("playlistItems"); got != 7 {
+		t.Errorf("playlistItems requests in second run = %d, want 7", got)
+	}
+	if run := store.runs[1]; run.Status != models.SyncSucceeded || run.EpisodesInserted != 52 || run.EpisodesRemoved != 2 {
+		t.Errorf("second run = %s, %d inserted, %d removed; want succeeded, 52 inserted, 2 removed", run.Status, run.EpisodesInserted, run.EpisodesRemoved)
+	}
+}
+
+// TestRunSyncMatchOrder memeriksa bahwa anime baru dibuat dari playlist channel
+// dengan priority tertinggi, berapa pun concurrency-nya.
+func TestRunSyncMatchOrder(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	for _, concurrency := range []int{1, 3} {
+		store := newMemStore(
+			models.Channel{ID: "UCxxnxya_32jcKj4yN1_kD7A", Name: "Muse Indonesia", Enabled: true},
+			models.Channel{ID: "UC0wNSTMWIL3qaorLx0jie6A", Name: "Ani-One Asia", Enabled: true, Priority: 1},
+		)
+		app := newTestApp(store, youtube.WithBaseURL(srv.URL))
+		app.ChannelConcurrency, app.PlaylistConcurrency = concurrency, concurrency
+		if err := app.runSync(context.Background(), models.TaskDiscovery); err != nil {
+			t.Fatalf("concurrency %d: %v", concurrency, err)
+		}
+
+		if got, want := animeTitles(store), []string{"Frieren: Beyond Journey's End", "Mushoku Tensei: Jobless Reincarnation", "Spy x Family"}; !slices.Equal(got, want) {
+			t.Errorf("concurrency %d: animes = %q, want %q", concurrency, got, want)
+		}
+		if len(store.reviews) != 1 || store.reviews[0].PlaylistID != museMushoku {
+			t.Errorf("concurrency %d: match reviews = %+v, want one review for %s", concurrency, store.reviews, museMushoku)
+		}
+	}
+}
+
+// TestRunSyncRejectedMatch memeriksa bahwa playlist yang kandidat anime-nya
+// ditolak tidak kembali ke anime itu, juga saat judulnya sama persis.
+func TestRunSyncRejectedMatch(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	store := newMemStore(
+		models.Channel{ID: "UCxxnxya_32jcKj4yN1_kD7A", Name: "Muse Indonesia", Enabled: true, Priority: 1},
+		models.Channel{ID: "UC0wNSTMWIL3qaorLx0jie6A", Name: "Ani-One Asia", Enabled: true},
+	)
+	store.animes = []models.Anime{{ID: 1, Title: "Mushoku Tensei"}}
+	rejected := 1
+	store.reviews = []models.AnimeMatchReview{
+		{ID: 1, PlaylistID: museMushoku, CandidateAnimeID: &rejected, Status: models.MatchRejected},
+		{ID: 2, PlaylistID: aniOneMushoku, CandidateAnimeID: &rejected, Status: models.MatchRejected},
+	}
+	if err := newTestApp(store, youtube.WithBaseURL(srv.URL)).runSync(context.Background(), models.TaskDiscovery); err != nil {
+		t.Fatal(err)
+	}
+
+	got := playlistAnimes(store)
+	want := map[string]string{museMushoku: "Mushoku Tensei (Muse Indonesia)", aniOneMushoku: "Mushoku Tensei: Jobless Reincarnation"}
+	for id, title := range want {
+		if got[id] != title {
+			t.Errorf("anime of playlist %s = %q, want %q", id, got[id], title)
+		}
+	}
+	if len(store.reviews) != 2 {
+		t.Errorf("match reviews = %+v, want no new reviews", store.reviews)
+	}
+}
+
+// TestAnimeMatcher memeriksa bahwa snapshot judul selalu terurut, jadi skor
+// yang sama jatuh ke anime tertua apa pun urutan judul dimasukkan.
+func TestAnimeMatcher(t *testing.T) {
+	var m animeMatcher
+	m.add(3, "Frieren")
+	m.add(2, "Frieren")
+	m.add(3, "Frieren")
+	m.add(1, "Spy x Family")
+	want := []titles.Candidate{{ID: 1, Title: "Spy x Family"}, {ID: 2, Title: "Frieren"}, {ID: 3, Title: "Frieren"}}
+	if !slices.Equal(m.candidates, want) {
+		t.Errorf("candidates = %+v, want %+v", m.candidates, want)
+	}
+	if best, _ := titles.BestMatch("Frieren", m.candidates); best.ID != 2 {
+		t.Errorf("best match = %+v, want anime 2", best)
+	}
+}
+
+// TestRunSyncConditional menjalankan beberapa run terhadap server yang sama
+// dengan ETag tersimpan di store.
+func TestRunSyncConditional(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	app := newTestApp(store, youtube.WithBaseURL(srv.URL), youtube.WithETagCache(store))
+	app.FullSyncInterval = 24 * time.Hour
+
+	if err := app.runSync(ctx, models.TaskDiscovery); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// Discovery berikutnya hanya mengirim request kondisional ke halaman
+	// terakhir setiap playlist dan semuanya dijawab 304.
+	items, notModified, videos := srv.Requests("playlistItems"), srv.NotModified("playlistItems"), srv.Requests("videos")
+	if err := app.runSync(ctx, models.TaskDiscovery); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if got := srv.Requests("playlistItems") - items; got != 5 {
+		t.Errorf("playlistItems requests in second run = %d, want 5", got)
+	}
+	if got := srv.NotModified("playlistItems") - notModified; got != 5 {
+		t.Errorf("playlistItems 304 responses in second run = %d, want 5", got)
+	}
+	if got := srv.Requests("videos") - videos; got != 0 {
+		t.Errorf("videos requests in second run = %d, want 0", got)
+	}
+	if run := store.runs[1]; run.EpisodesInserted != 0 || run.EpisodesUpdated != 0 {
+		t.Errorf("second run inserted %d and updated %d episodes, want none", run.EpisodesInserted, run.EpisodesUpdated)
+	}
+
+	// Perubahan di halaman kedua Kusuriya tanpa mengubah halaman pertama: satu
+	// video diganti video lain dan satu video diprivat.
+	var replaced, privated, added string
+	fixtures := editFixtures(t, func(items map[string][]map[string]any) {
+		position := 0
+		for _, item := range items["playlistItems"] {
+			if item["snippet"].(map[string]any)["playlistId"] != asiaKusuriya {
+				continue
+			}
+			switch position {
+			case 54:
+				replaced = videoIDOf(item)
+				added = replaced + "-reupload"
+				item["snippet"].(map[string]any)["resourceId"].(map[string]any)["videoId"] = added
+			case 57:
+				privated = videoIDOf(item)
+			}
+			position++
+		}
+		for _, video := range items["videos"] {
+			switch video["id"] {
+			case replaced:
+				reupload := cloneJSON(video)
+				reupload["id"] = added
+				items["videos"] = append(items["videos"], reupload)
+			case privated:
+				video["status"].(map[string]any)["privacyStatus"] = "private"
+			}
+		}
+	})
+	if err := srv.Reload(fixtures); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.runSync(ctx, models.TaskReconcile); err != nil {
+		t.Fatalf("reconcile run: %v", err)
+	}
+
+	wantUnavailable := map[string]string{replaced: models.EpisodeRemoved, privated: models.EpisodePrivate}
+	if got := unavailableEpisodes(store); !maps.Equal(got, wantUnavailable) {
+		t.Errorf("unavailable episodes = %v, want %v", got, wantUnavailable)
+	}
+	if ep, ok := store.episodes[added]; !ok || ep.PlaylistID != asiaKusuriya {
+		t.Errorf("re-uploaded video %s was not stored in playlist %s", added, asiaKusuriya)
+	}
+	if run := store.runs[2]; run.Status != models.SyncSucceeded || run.EpisodesInserted != 1 || run.EpisodesRemoved != 1 {
+		t.Errorf("reconcile run = %s, %d inserted, %d removed; want succeeded, 1 inserted, 1 removed", run.Status, run.EpisodesInserted, run.EpisodesRemoved)
+	}
+}
+
+// TestRunSyncRetriesFailedPlaylist memeriksa bahwa playlist yang gagal
+// diproses setelah halamannya diambil diproses ulang pada discovery berikutnya,
+// walaupun halaman terakhirnya tidak berubah lagi.
+func TestRunSyncRetriesFailedPlaylist(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore()
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	app := newTestApp(store, youtube.WithBaseURL(srv.URL), youtube.WithETagCache(store))
+	app.FullSyncInterval = 24 * time.Hour
+	if err := app.runSync(ctx, models.TaskDiscovery); err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// Episode baru masuk di halaman kedua Kusuriya, tetapi videos.list gagal
+	// sekali sehingga playlist itu gagal disinkronkan.
+	if err := srv.Reload(editFixtures(t, func(items map[string][]map[string]any) {
+		appendEpisodes(items, asiaKusuriya, 3)
+	})); err != nil {
+		t.Fatal(err)
+	}
+	srv.FailNext("videos", http.StatusBadRequest, "badRequest")
+	if err := app.runSync(ctx, models.TaskDiscovery); err == nil {
+		t.Fatal("second run succeeded, want the failed playlist reported")
+	}
+	if run := store.runs[1]; run.ErrorCount != 1 || run.EpisodesInserted != 0 {
+		t.Fatalf("second run = %d errors, %d inserted; want 1 error, 0 inserted", run.ErrorCount, run.EpisodesInserted)
+	}
+
+	if err := app.runSync(ctx, models.TaskDiscovery); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if run := store.runs[2]; run.ErrorCount != 0 || run.EpisodesInserted != 3 {
+		t.Errorf("third run = %d errors, %d inserted; want 0 errors, 3 inserted", run.ErrorCount, run.EpisodesInserted)
+	}
+	if got := availableEpisodes(store)[asiaKusuriya]; got != 63 {
+		t.Errorf("available Kusuriya episodes = %d, want 63", got)
+	}
+}
+
+// TestRunSyncBackfillsSeasons memeriksa bahwa playlist lama tanpa season,
+// termasuk yang sudah tidak ada di channel, mendapat season dari judulnya.
+func TestRunSyncBackfillsSeasons(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	store := newMemStore()
+	store.animes = []models.Anime{{ID: 1, Title: "Mushoku Tensei"}}
+	animeID := 1
+	store.playlists["PLgone"] = models.Playlist{ID: "PLgone", AnimeID: &animeID, Title: "Mushoku Tensei S2 Part 2 (Sub Indo)"}
+	store.playlists["PLpending"] = models.Playlist{ID: "PLpending", Title: "Mushoku Tensei Season 3"}
+	if err := newTestApp(store, youtube.WithBaseURL(srv.URL)).runSync(context.Background(), models.TaskDiscovery); err != nil {
+		t.Fatal(err)
+	}
+
+	gone := store.playlists["PLgone"]
+	if gone.SeasonID == nil {
+		t.Fatal("season of PLgone was not backfilled")
+	}
+	if got := store.seasons[*gone.SeasonID-1]; got.AnimeID != animeID || got.Label != "Season 2 Part 2" {
+		t.Errorf("season of PLgone = %+v, want Season 2 Part 2 of anime %d", got, animeID)
+	}
+	if p := store.playlists["PLpending"]; p.SeasonID != nil {
+		t.Errorf("playlist without anime got season %d", *p.SeasonID)
+	}
+}
+
+// TestRunSyncReplay memutar ulang rekaman satu channel dan memeriksa
+// playlist dan video mana yang dianggap episode.
+func TestRunSyncReplay(t *testing.T) {
+	recorder, err := youtube.NewRecorder("testdata/cassettes/muse-indonesia.json", youtube.CassetteReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemStore(models.Channel{ID: "UCxxnxya_32jcKj4yN1_kD7A", Name: "Muse Indonesia", Enabled: true})
+	// PV yang tersimpan sebelum filter durasi ada.
+	const storedShort = "hck7TBX1z9b"
+	store.episodes[storedShort] = models.Episode{VideoID: storedShort, PlaylistID: museMushoku, Title: "PV"}
+	app := newTestApp(store, youtube.WithHTTPClient(&http.Client{Transport: recorder}))
+	if err := app.runSync(context.Background(), models.TaskDiscovery); err != nil {
+		t.Fatalf("replay run: %v", err)
+	}
+
+	type playlistClass struct {
+		Anime, Season, Language string
+	}
+	wantPlaylists := map[string]playlistClass{
+		museMushoku: {Anime: "Mushoku Tensei", Season: "Season 2", Language: "id"},
+		museFrieren: {Anime: "Frieren: Beyond Journey's End", Season: "Season 1", Language: "id"},
+	}
+	gotPlaylists := make(map[string]playlistClass)
+	for id, p := range store.playlists {
+		gotPlaylists[id] = playlistClass{Anime: playlistAnimes(store)[id], Season: store.seasons[*p.SeasonID-1].Label, Language: p.Language}
+	}
+	if !maps.Equal(gotPlaylists, wantPlaylists) {
+		t.Errorf("playlists = %+v, want %+v", gotPlaylists, wantPlaylists)
+	}
+
+	// Video pendek (PV) dan video yang sudah dihapus tidak disimpan sebagai episode.
+	type episodeClass struct {
+		Playlist string
+		Number   int
+		Views    int64
+	}
+	wantEpisodes := map[string]episodeClass{
+		"R9cZ0ZCbqw3": {museMushoku, 1, 866509},
+		"mlIxGkE-wU0": {museMushoku, 2, 4669487},
+		"588DPHjPinO": {museMushoku, 3, 1429509},
+		"bX4_k6gn6KU": {museFrieren, 1, 8755570},
+		"5ODWeb8SHDA": {museFrieren, 2, 7478748},
+		"Va3Si61FW3f": {museFrieren, 3, 10381402},
+	}
+	gotEpisodes := make(map[string]episodeClass)
+	for id, ep := range store.episodes {
+		if ep.UnavailableAt != nil {
+			continue
+		}
+		c := episodeClass{Playlist: ep.PlaylistID, Views: ep.ViewCount}
+		if ep.EpisodeNumber != nil {
+			c.Number = *ep.EpisodeNumber
+		}
+		gotEpisodes[id] = c
+	}
+	if !maps.Equal(gotEpisodes, wantEpisodes) {
+		t.Errorf("episodes = %v, want %v", gotEpisodes, wantEpisodes)
+	}
+	if got, want := unavailableEpisodes(store), map[string]string{storedShort: models.EpisodeRemoved}; !maps.Equal(got, want) {
+		t.Errorf("unavailable episodes = %v, want %v", got, want)
+	}
+	if run := store.runs[0]; run.Status != models.SyncSucceeded || run.PlaylistsSeen != 2 {
+		t.Errorf("run = %s with %d playlists, want succeeded with 2 playlists", run.Status, run.PlaylistsSeen)
+	}
+}
+
+func animeTitles(store *memStore) []string {
+	var got []string
+	for _, a := range store.animes {
+		got = append(got, a.Title)
+	}
+	slices.Sort(got)
+	return got
+}
+
+// playlistAnimes memetakan setiap playlist tersimpan ke judul animenya.
+func playlistAnimes(store *memStore) map[string]string {
+	got := make(map[string]string)
+	for id, p := range store.playlists {
+		for _, a := range store.animes {
+			if p.AnimeID != nil && *p.AnimeID == a.ID {
+				got[id] = a.Title
+			}
+		}
+	}
+	return got
+}
+
+// availableEpisodes menghitung episode yang masih tersedia per playlist.
+func availableEpisodes(store *memStore) map[string]int {
+	got := make(map[string]int)
+	for _, ep := range store.episodes {
+		if ep.UnavailableAt == nil {
+			got[ep.PlaylistID]++
+		}
+	}
+	return got
+}
+
+// unavailableEpisodes memetakan episode yang tidak tersedia ke alasannya.
+func unavailableEpisodes(store *memStore) map[string]string {
+	got := make(map[string]string)
+	for id, ep := range store.episodes {
+		if ep.UnavailableAt != nil {
+			got[id] = *ep.UnavailableReason
+		}
+	}
+	return got
+}
+
+// editFixtures menyalin fixture bawaan youtubetest, mengubahnya lewat edit
+// (dikelompokkan per nama file tanpa ekstensi) dan mengembalikannya untuk NewServerFS.
+func editFixtures(t *testing.T, edit func(items map[string][]map[string]any)) fs.FS {
+	t.Helper()
+	names := []string{"channels", "playlists", "playlistItems", "videos"}
+	items := make(map[string][]map[string]any)
+	for _, name := range names {
+		data, err := fs.ReadFile(youtubetest.Fixtures(), name+".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var list []map[string]any
+		if err := json.Unmarshal(data, &list); err != nil {
+			t.Fatal(err)
+		}
+		items[name] = list
+	}
+	edit(items)
+
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		data, err := json.Marshal(items[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsys[name+".json"] = &fstest.MapFile{Data: data}
+	}
+	return fsys
+}
+
+// appendEpisodes menambahkan n episode baru di akhir playlist, disalin dari
+// item terakhir playlist itu beserta videonya.
+func appendEpisodes(items map[string][]map[string]any, playlistID string, n int) {
+	var last map[string]any
+	for _, item := range items["playlistItems"] {
+		if item["snippet"].(map[string]any)["playlistId"] == playlistID {
+			last = item
+		}
+	}
+	var lastVideo map[string]any
+	for _, video := range items["videos"] {
+		if video["id"] == videoIDOf(last) {
+			lastVideo = video
+		}
+	}
+	published, _ := time.Parse(time.RFC3339, last["snippet"].(map[string]any)["publishedAt"].(string))
+
+	for i := 1; i <= n; i++ {
+		videoID := fmt.Sprintf("%s-new%02d", playlistID[:8], i)
+		item := cloneJSON(last)
+		item["id"] = fmt.Sprintf("%s-item%02d", playlistID, i)
+		snippet := item["snippet"].(map[string]any)
+		snippet["title"] = fmt.Sprintf("New episode %d", i)
+		snippet["publishedAt"] = published.AddDate(0, 0, 7*i).Format(time.RFC3339)
+		snippet["resourceId"].(map[string]any)["videoId"] = videoID
+		items["playlistItems"] = append(items["playlistItems"], item)
+
+		video := cloneJSON(lastVideo)
+		video["id"] = videoID
+		items["videos"] = append(items["videos"], video)
+	}
+}
+
+func videoIDOf(item map[string]any) string {
+	return item["snippet"].(map[string]any)["resourceId"].(map[string]any)["videoId"].(string)
+}
+
+func cloneJSON(v map[string]any) map[string]any {
+	data, _ := json.Marshal(v)
+	var clone map[string]any
+	json.Unmarshal(data, &clone)
+	return clone
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"alyo/internal/core/models"
+	"alyo/internal/youtube"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// task adalah satu pekerjaan terjadwal worker.
+type task struct {
+	name  string
+	lease string // Task dengan lease yang sama tidak pernah berjalan bersamaan
+	// env adalah environment variable untuk jadwal cron task ini (dengan detik,
+	// misalnya "0 */15 * * * *" atau "@every 15m"); "off" mematikan task.
+	env             string
+	defaultSchedule string
+	// onStart berarti task juga dijalankan sekali saat worker baru menyala.
+	onStart bool
+	// run mengembalikan error jika run gagal atau sebagian pekerjaannya gagal.
+	run func(ctx context.Context) error
+}
+
+// tasks mengembalikan semua task worker dalam urutan saat dijalankan berurutan.
+func (app *AppConfig) tasks() []task {
+	return []task{
+		{
+			name: models.TaskAvatars, lease: models.TaskAvatars,
+			env: "WORKER_SCHEDULE_AVATARS", defaultSchedule: "0 0 4 * * *",
+			onStart: true, run: app.runAvatars,
+		},
+		{
+			name: models.TaskDiscovery, lease: syncLeaseName,
+			env: "WORKER_SCHEDULE_DISCOVERY", defaultSchedule: "0 */15 * * * *",
+			onStart: true, run: func(ctx context.Context) error { return app.runSync(ctx, models.TaskDiscovery) },
+		},
+		{
+			name: models.TaskReconcile, lease: syncLeaseName,
+			env: "WORKER_SCHEDULE_RECONCILE", defaultSchedule: "0 0 3 * * *",
+			run: func(ctx context.Context) error { return app.runSync(ctx, models.TaskReconcile) },
+		},
+		{
+			name: models.TaskViews, lease: models.TaskViews,
+			env: "WORKER_SCHEDULE_VIEWS", defaultSchedule: "0 5 * * * *",
+			onStart: true, run: app.runViews,
+		},
+		{
+			name: models.TaskDownsample, lease: models.TaskDownsample,
+			env: "WORKER_SCHEDULE_DOWNSAMPLE", defaultSchedule: "0 30 4 * * *",
+			run: app.runDownsample,
+		},
+	}
+}
+
+// findTask mencari task berdasarkan nama.
+func (app *AppConfig) findTask(name string) (task, bool) {
+	for _, t := range app.tasks() {
+		if t.name == name {
+			return t, true
+		}
+	}
+	return task{}, false
+}
+
+// runTask menjalankan satu task di bawah lease-nya. Mengembalikan error jika
+// task gagal atau tidak dijalankan karena lease sedang dipegang instance lain.
+func (app *AppConfig) runTask(ctx context.Context, t task) error {
+	log.Printf("--- Running task %s ---", t.name)
+	err := app.withLease(ctx, t.lease, t.run)
+	log.Printf("--- Task %s finished ---", t.name)
+	return err
+}
+
+// scheduleTasks mendaftarkan setiap task ke c sesuai jadwal dari environment.
+// Task yang masih berjalan saat jadwal berikutnya tiba dilewati, bukan ditumpuk.
+func (app *AppConfig) scheduleTasks(ctx context.Context, c *cron.Cron) error {
+	logger := cron.VerbosePrintfLogger(log.New(log.Writer(), "cron: ", log.Flags()))
+	for _, t := range app.tasks() {
+		schedule := strings.TrimSpace(os.Getenv(t.env))
+		if schedule == "" {
+			schedule = t.defaultSchedule
+		}
+		if schedule == "off" {
+			log.Printf("Task %s is disabled (%s=off)", t.name, t.env)
+			continue
+		}
+		job := cron.NewChain(cron.SkipIfStillRunning(logger)).Then(cron.FuncJob(func() {
+			app.runTask(ctx, t)
+		}))
+		if _, err := c.AddJob(schedule, job); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", t.env, schedule, err)
+		}
+		log.Printf("Task %s scheduled at %q", t.name, schedule)
+	}
+	return nil
+}
+
+// runContext menerapkan RunTimeout ke ctx dan menambahkan pembatalan dengan penyebab.
+func (app *AppConfig) runContext(ctx context.Context) (context.Context, context.CancelCauseFunc) {
+	stopTimeout := func() {}
+	if app.RunTimeout > 0 {
+		ctx, stopTimeout = context.WithTimeout(ctx, app.RunTimeout)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, func(cause error) {
+		cancel(cause)
+		stopTimeout()
+	}
+}
+
+// startRun mencatat awal run sebuah task di riwayat sync run. Jika gagal,
+// run tetap berjalan tanpa riwayat (ID bernilai 0).
+func (app *AppConfig) startRun(ctx context.Context, taskName string) models.SyncRun {
+	run := models.SyncRun{Task: taskName, StartedAt: time.Now(), Status: models.SyncRunning}
+	id, err := app.Store.CreateSyncRun(ctx, taskName, run.StartedAt)
+	if err != nil {
+		log.Printf("WARN: Could not record %s run, continuing without history: %v", taskName, err)
+	}
+	run.ID = id
+	return run
+}
+
+// finishRun menentukan status akhir run, menyimpannya ke riwayat dan mencatat
+// ringkasannya di log. err adalah penyebab run berhenti lebih awal; jika nil,
+// penyebab pembatalan ctx yang dipakai. Mengembalikan error jika run gagal
+// atau ada pekerjaan yang gagal, sehingga mode -once bisa keluar dengan status non-zero.
+func (app *AppConfig) finishRun(ctx context.Context, run *models.SyncRun, err error) error {
+	run.Status = models.SyncSucceeded
+	if run.ErrorCount > 0 {
+		run.Status = models.SyncPartial
+	}
+	if err == nil {
+		err = context.Cause(ctx)
+	}
+	if err != nil {
+		log.Printf("ERROR: Task %s stopped early (%v), remaining work will be done on the next run", run.Task, err)
+		run.Status = models.SyncFailed
+		msg := err.Error()
+		run.Error = &msg
+	}
+
+	// Ringkasan tetap dicatat walaupun run dibatalkan.
+	summaryCtx := context.WithoutCancel(ctx)
+	if run.ID != 0 {
+		finishedAt := time.Now()
+		run.FinishedAt = &finishedAt
+		if err := app.Store.FinishSyncRun(summaryCtx, *run); err != nil {
+			log.Printf("WARN: Could not record result of sync run %d: %v", run.ID, err)
+		}
+	}
+	log.Printf("Task %s %s: %d playlists, %d episodes inserted, %d updated, %d removed, %d errors, %d quota units", run.Task, run.Status, run.PlaylistsSeen, run.EpisodesInserted, run.EpisodesUpdated, run.EpisodesRemoved, run.ErrorCount, run.APIUnits)
+	switch usage, ok, err := app.YouTubeClient.QuotaUsage(summaryCtx); {
+	case err != nil:
+		log.Printf("WARN: Could not load quota usage: %v", err)
+	case ok:
+		log.Printf("Quota usage for %s: %d of %d units used, %d remaining", usage.Day, usage.Used, usage.Budget, usage.Remaining)
+	}
+	for _, k := range app.YouTubeClient.KeyStatuses() {
+		log.Printf("API %s: %s, %d calls this process", k.Label, k.State, k.Calls)
+	}
+
+	switch {
+	case err != nil:
+		return err
+	case run.ErrorCount > 0:
+		return fmt.Errorf("task %s finished with %d errors", run.Task, run.ErrorCount)
+	}
+	return nil
+}
+
+// runAvatars memperbarui nama, URL dan foto profil setiap channel target.
+// Foto yang sudah tersimpan di disk tidak diunduh ulang.
+func (app *AppConfig) runAvatars(ctx context.Context) error {
+	ctx, cancel := app.runContext(ctx)
+	defer cancel(nil)
+	run := app.startRun(ctx, models.TaskAvatars)
+	ctx, units := youtube.WithUnitCounter(ctx)
+
+	channels, err := app.targetChannels(ctx)
+	if err != nil {
+		err = fmt.Errorf("could not load target channels: %w", err)
+	}
+	for _, channel := range channels {
+		if err != nil {
+			break
+		}
+		if err = app.syncChannelAvatar(ctx, channel); err != nil {
+			log.Printf("ERROR: Channel %s: %v", channel.Name, err)
+			run.ErrorCount++
+			if !isFatalAPIError(err) {
+				err = nil
+			}
+		}
+	}
+	run.APIUnits = units.Units()
+	return app.finishRun(ctx, &run, err)
+}
+
+// syncChannelAvatar mengunduh foto profil satu channel jika belum ada lalu menyimpan channel-nya.
+func (app *AppConfig) syncChannelAvatar(ctx context.Context, channel models.Channel) error {
+	name, id := channel.Name, channel.ID
+	profilePicURL, err := app.YouTubeClient.GetChannelProfilePicture(ctx, id)
+	if err != nil {
+		return fmt.Errorf("could not get profile picture: %w", err)
+	}
+
+	var localImagePath string
+	if profilePicURL != "" {
+		localImagePath = fmt.Sprintf("/img/channels/%s.jpg", id)
+		fullPath := filepath.Join("web", strings.TrimPrefix(localImagePath, "/"))
+
+		if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+			errDownload := downloadAndSaveImage(ctx, profilePicURL, fullPath)
+			if errDownload != nil {
+				log.Printf("ERROR: Could not download image for channel %s: %v", name, errDownload)
+				localImagePath = ""
+			}
+		}
+	}
+
+	channelURL := "https://www.youtube.com/channel/" + id
+	err = app.Store.UpsertChannel(ctx, models.Channel{ID: id, Name: name, URL: channelURL, ProfilePictureURL: &localImagePath})
+	if err != nil {
+		return fmt.Errorf("could not upsert channel: %w", err)
+	}
+	return nil
+}
+
+// runViews mengambil statistik terbaru semua episode yang masih tersedia di
+// channel target, menghitung ulang total views setiap anime dari seluruh
+// playlist-nya, lalu mencatat snapshot views episode dan anime untuk
+// perhitungan tren. Biayanya 1 unit quota per 50 episode.
+func (app *AppConfig) runViews(ctx context.Context) error {
+	ctx, cancel := app.runContext(ctx)
+	defer cancel(nil)
+	run := app.startRun(ctx, models.TaskViews)
+	ctx, units := youtube.WithUnitCounter(ctx)
+
+	err := app.refreshViews(ctx, &run)
+	run.APIUnits = units.Units()
+	return app.finishRun(ctx, &run, err)
+}
+
+func (app *AppConfig) refreshViews(ctx context.Context, run *models.SyncRun) error {
+	channels, err := app.targetChannels(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load target channels: %w", err)
+	}
+	channelIDs := make([]string, len(channels))
+	for i, ch := range channels {
+		channelIDs[i] = ch.ID
+	}
+
+	videoIDs, err := app.Store.GetAvailableEpisodeIDs(ctx, channelIDs)
+	if err != nil {
+		return fmt.Errorf("could not load episodes: %w", err)
+	}
+	details, err := app.fetchVideoDetails(ctx, videoIDs)
+	if err != nil {
+		return fmt.Errorf("could not get video details: %w", err)
+	}
+
+	// Video yang hilang dari videos.list ditangani reconcile; statistiknya dibiarkan.
+	stats := make([]models.EpisodeStatistics, 0, len(details))
+	for _, videoID := range videoIDs {
+		detail, ok := details[videoID]
+		if !ok {
+			continue
+		}
+		st := models.EpisodeStatistics{VideoID: videoID}
+		st.ViewCount, _ = strconv.ParseInt(detail.Statistics.ViewCount, 10, 64)
+		st.LikeCount, _ = strconv.ParseInt(detail.Statistics.LikeCount, 10, 64)
+		st.CommentCount, _ = strconv.ParseInt(detail.Statistics.CommentCount, 10, 64)
+		stats = append(stats, st)
+	}
+
+	updated, err := app.Store.UpdateEpisodeStatistics(ctx, stats)
+	if err != nil {
+		return fmt.Errorf("could not save episode statistics: %w", err)
+	}
+	run.EpisodesUpdated = int(updated)
+
+	animes, err := app.Store.RecomputeAnimeAggregates(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not recompute anime aggregates: %w", err)
+	}
+	if err := app.Store.SaveViewSnapshots(ctx, stats); err != nil {
+		return fmt.Errorf("could not save view snapshots: %w", err)
+	}
+	log.Printf("Views: %d of %d episodes changed, %d animes updated", updated, len(videoIDs), animes)
+	return nil
+}
+
+// runDownsample merapikan snapshot views lama (lihat SnapshotKeepHourly dan
+// SnapshotKeepDaily) dan menghapus respons YouTube tersimpan yang lebih tua
+// dari ETagRetention, agar kedua tabel tidak terus membesar.
+func (app *AppConfig) runDownsample(ctx context.Context) error {
+	ctx, cancel := app.runContext(ctx)
+	defer cancel(nil)
+	run := app.startRun(ctx, models.TaskDownsample)
+
+	err := app.downsample(ctx)
+	return app.finishRun(ctx, &run, err)
+}
+
+func (app *AppConfig) downsample(ctx context.Context) error {
+	deleted, err := app.Store.DownsampleViewSnapshots(ctx, app.SnapshotKeepHourly, app.SnapshotKeepDaily)
+	if err != nil {
+		return fmt.Errorf("could not downsample view snapshots: %w", err)
+	}
+	log.Printf("Downsample: %d old view snapshots removed", deleted)
+
+	pruned, err := app.Store.PruneCachedResponses(ctx, app.ETagRetention)
+	if err != nil {
+		return fmt.Errorf("could not prune cached responses: %w", err)
+	}
+	log.Printf("Downsample: %d cached responses older than %s removed", pruned, app.ETagRetention)
+	return nil
+}
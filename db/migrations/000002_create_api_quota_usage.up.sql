@@ -0,0 +1,10 @@
+-- File: 000002_create_api_quota_usage.up.sql
+-- Mencatat pemakaian quota YouTube Data API per hari (Pacific Time) dan per endpoint
+
+CREATE TABLE IF NOT EXISTS api_quota_usage (
+    usage_date DATE NOT NULL,
+    endpoint VARCHAR(64) NOT NULL,
+    units BIGINT NOT NULL DEFAULT 0,
+    calls BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (usage_date, endpoint)
+);
@@ -0,0 +1,9 @@
+-- File: 000003_create_api_etags.up.sql
+-- Menyimpan ETag dan body respons terakhir per resource YouTube untuk request kondisional
+
+CREATE TABLE IF NOT EXISTS api_etags (
+    resource_key TEXT PRIMARY KEY,
+    etag VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
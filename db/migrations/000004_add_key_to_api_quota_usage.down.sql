@@ -0,0 +1,11 @@
+-- Pemakaian semua key dijumlahkan per hari dan endpoint sebelum key_label dihapus, agar tidak ada unit yang hilang
+CREATE TEMP TABLE api_quota_usage_totals AS
+    SELECT usage_date, endpoint, SUM(units) AS units, SUM(calls) AS calls
+    FROM api_quota_usage GROUP BY usage_date, endpoint;
+DELETE FROM api_quota_usage;
+ALTER TABLE api_quota_usage DROP CONSTRAINT IF EXISTS api_quota_usage_pkey;
+ALTER TABLE api_quota_usage DROP COLUMN IF EXISTS key_label;
+ALTER TABLE api_quota_usage ADD PRIMARY KEY (usage_date, endpoint);
+INSERT INTO api_quota_usage (usage_date, endpoint, units, calls)
+    SELECT usage_date, endpoint, units, calls FROM api_quota_usage_totals;
+DROP TABLE api_quota_usage_totals;
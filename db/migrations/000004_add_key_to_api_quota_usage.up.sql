@@ -0,0 +1,6 @@
+-- File: 000004_add_key_to_api_quota_usage.up.sql
+-- Pemakaian quota dicatat per API key (berupa label sidik jari, bukan key aslinya)
+
+ALTER TABLE api_quota_usage ADD COLUMN IF NOT EXISTS key_label VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE api_quota_usage DROP CONSTRAINT IF EXISTS api_quota_usage_pkey;
+ALTER TABLE api_quota_usage ADD PRIMARY KEY (usage_date, endpoint, key_label);
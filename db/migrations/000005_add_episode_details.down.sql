@@ -0,0 +1,8 @@
+ALTER TABLE episodes
+    DROP COLUMN IF EXISTS duration_seconds,
+    DROP COLUMN IF EXISTS privacy_status,
+    DROP COLUMN IF EXISTS embeddable,
+    DROP COLUMN IF EXISTS region_allowed,
+    DROP COLUMN IF EXISTS region_blocked,
+    DROP COLUMN IF EXISTS like_count,
+    DROP COLUMN IF EXISTS comment_count;
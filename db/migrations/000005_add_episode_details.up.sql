@@ -0,0 +1,12 @@
+-- File: 000005_add_episode_details.up.sql
+-- Detail video dari contentDetails, status dan statistics YouTube
+
+ALTER TABLE episodes
+    ADD COLUMN IF NOT EXISTS duration_seconds INT,
+    ADD COLUMN IF NOT EXISTS privacy_status VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS embeddable BOOLEAN,
+    -- Kode negara ISO 3166-1 alpha-2 dipisah koma, sama seperti kolom 'languages'
+    ADD COLUMN IF NOT EXISTS region_allowed TEXT,
+    ADD COLUMN IF NOT EXISTS region_blocked TEXT,
+    ADD COLUMN IF NOT EXISTS like_count BIGINT DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS comment_count BIGINT DEFAULT 0;
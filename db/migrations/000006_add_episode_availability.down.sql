@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_episodes_available;
+ALTER TABLE episodes
+    DROP COLUMN IF EXISTS unavailable_at,
+    DROP COLUMN IF EXISTS unavailable_reason;
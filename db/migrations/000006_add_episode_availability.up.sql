@@ -0,0 +1,8 @@
+-- File: 000006_add_episode_availability.up.sql
+-- Episode yang dihapus atau diprivat di YouTube ditandai tidak tersedia, bukan dihapus
+
+ALTER TABLE episodes
+    ADD COLUMN IF NOT EXISTS unavailable_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS unavailable_reason VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_episodes_available ON episodes (playlist_id) WHERE unavailable_at IS NULL;
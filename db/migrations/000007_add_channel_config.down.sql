@@ -0,0 +1,6 @@
+ALTER TABLE channels
+    DROP COLUMN IF EXISTS enabled,
+    DROP COLUMN IF EXISTS default_language,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS playlist_include,
+    DROP COLUMN IF EXISTS playlist_exclude;
@@ -0,0 +1,19 @@
+-- File: 000007_add_channel_config.up.sql
+-- Konfigurasi channel target worker disimpan di tabel channels, bukan lagi di kode
+
+ALTER TABLE channels
+    ADD COLUMN IF NOT EXISTS enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    -- Bahasa playlist jika judulnya tidak menyebutkan bahasa
+    ADD COLUMN IF NOT EXISTS default_language VARCHAR(10),
+    -- Channel dengan priority lebih tinggi diproses lebih dulu
+    ADD COLUMN IF NOT EXISTS priority INT NOT NULL DEFAULT 0,
+    -- Kata kunci judul playlist dipisah koma
+    ADD COLUMN IF NOT EXISTS playlist_include TEXT,
+    ADD COLUMN IF NOT EXISTS playlist_exclude TEXT;
+
+-- Channel yang sebelumnya ada di targetChannels
+INSERT INTO channels (channel_id, name, url, default_language, priority) VALUES
+    ('UCxxnxya_32jcKj4yN1_kD7A', 'Muse Indonesia', 'https://www.youtube.com/channel/UCxxnxya_32jcKj4yN1_kD7A', 'id', 0),
+    ('UC0wNSTMWIL3qaorLx0jie6A', 'Ani-One Asia', 'https://www.youtube.com/channel/UC0wNSTMWIL3qaorLx0jie6A', NULL, 0),
+    ('UCGbshtvS9t-8CW11W7TooQg', 'Muse Asia', 'https://www.youtube.com/channel/UCGbshtvS9t-8CW11W7TooQg', NULL, 0)
+ON CONFLICT (channel_id) DO UPDATE SET default_language = EXCLUDED.default_language;
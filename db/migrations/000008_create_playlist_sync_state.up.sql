@@ -0,0 +1,15 @@
+-- File: 000008_create_playlist_sync_state.up.sql
+-- Status sinkronisasi per playlist untuk sinkronisasi inkremental
+
+CREATE TABLE IF NOT EXISTS playlist_sync_state (
+    playlist_id VARCHAR(255) PRIMARY KEY,
+    -- Watermark: video dengan publishedAt (waktu masuk playlist) terbaru yang sudah diproses
+    last_video_id VARCHAR(255),
+    last_published_at TIMESTAMPTZ,
+    -- Token halaman terakhir playlist; kosong jika playlist hanya satu halaman
+    last_page_token TEXT NOT NULL DEFAULT '',
+    item_count INT NOT NULL DEFAULT 0,
+    last_full_sync_at TIMESTAMPTZ,
+    last_synced_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (playlist_id) REFERENCES playlists(playlist_id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sync_run_playlists;
+DROP TABLE IF EXISTS sync_run_channels;
+DROP TABLE IF EXISTS sync_runs;
@@ -0,0 +1,53 @@
+-- File: 000009_create_sync_runs.up.sql
+-- Riwayat run worker beserta rincian per channel dan per playlist
+
+CREATE TABLE IF NOT EXISTS sync_runs (
+    run_id SERIAL PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ,
+    -- running, succeeded, partial (sebagian playlist gagal) atau failed (run berhenti lebih awal)
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    playlists_seen INT NOT NULL DEFAULT 0,
+    episodes_inserted INT NOT NULL DEFAULT 0,
+    episodes_updated INT NOT NULL DEFAULT 0,
+    episodes_removed INT NOT NULL DEFAULT 0,
+    api_units BIGINT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE TABLE IF NOT EXISTS sync_run_channels (
+    run_id INT NOT NULL,
+    channel_id VARCHAR(255) NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    playlists_seen INT NOT NULL DEFAULT 0,
+    episodes_inserted INT NOT NULL DEFAULT 0,
+    episodes_updated INT NOT NULL DEFAULT 0,
+    episodes_removed INT NOT NULL DEFAULT 0,
+    api_units BIGINT NOT NULL DEFAULT 0,
+    error_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    PRIMARY KEY (run_id, channel_id),
+    FOREIGN KEY (run_id) REFERENCES sync_runs(run_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sync_run_playlists (
+    run_id INT NOT NULL,
+    playlist_id VARCHAR(255) NOT NULL,
+    channel_id VARCHAR(255) NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    -- synced, unchanged atau failed
+    status VARCHAR(20) NOT NULL,
+    full_sync BOOLEAN NOT NULL DEFAULT FALSE,
+    episodes_inserted INT NOT NULL DEFAULT 0,
+    episodes_updated INT NOT NULL DEFAULT 0,
+    episodes_removed INT NOT NULL DEFAULT 0,
+    api_units BIGINT NOT NULL DEFAULT 0,
+    error TEXT,
+    PRIMARY KEY (run_id, playlist_id),
+    FOREIGN KEY (run_id) REFERENCES sync_runs(run_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_started_at ON sync_runs(started_at);
@@ -0,0 +1,12 @@
+-- File: 000010_create_worker_leases.up.sql
+-- Lease agar hanya satu instance worker yang menjalankan sinkronisasi.
+-- Dipakai sebagai pengganti advisory lock karena pgbouncer berjalan dengan pool_mode = transaction.
+
+CREATE TABLE IF NOT EXISTS worker_leases (
+    name VARCHAR(64) PRIMARY KEY,
+    holder VARCHAR(255) NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL,
+    heartbeat_at TIMESTAMPTZ NOT NULL,
+    -- Lease yang tidak diperbarui sampai waktu ini dianggap milik instance yang mati
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_sync_runs_task_started_at;
+ALTER TABLE sync_runs
+    DROP COLUMN IF EXISTS task;
@@ -0,0 +1,7 @@
+-- File: 000011_add_task_to_sync_runs.up.sql
+-- Worker menjalankan beberapa task terjadwal (discovery, reconcile, views, avatars); riwayat run mencatat task-nya
+
+ALTER TABLE sync_runs
+    ADD COLUMN IF NOT EXISTS task VARCHAR(20) NOT NULL DEFAULT 'sync';
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_task_started_at ON sync_runs(task, started_at);
@@ -0,0 +1,24 @@
+-- File: 000012_create_anime_match_reviews.up.sql
+-- Antrean review untuk playlist yang judulnya mirip anime yang sudah ada tetapi tidak cukup yakin untuk digabung otomatis
+
+CREATE TABLE IF NOT EXISTS anime_match_reviews (
+    review_id SERIAL PRIMARY KEY,
+    playlist_id VARCHAR(255) NOT NULL UNIQUE,
+    channel_id VARCHAR(255) NOT NULL,
+    playlist_title VARCHAR(255) NOT NULL,
+    -- Judul anime hasil ekstraksi dari judul playlist
+    extracted_title VARCHAR(255) NOT NULL,
+    candidate_anime_id INT,
+    candidate_title VARCHAR(255),
+    score REAL NOT NULL,
+    -- pending, accepted (gabung ke anime) atau rejected (buat anime baru)
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    resolved_anime_id INT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMPTZ,
+    FOREIGN KEY (channel_id) REFERENCES channels(channel_id) ON DELETE CASCADE,
+    FOREIGN KEY (candidate_anime_id) REFERENCES animes(anime_id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_anime_id) REFERENCES animes(anime_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_anime_match_reviews_status ON anime_match_reviews(status, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE playlists DROP COLUMN IF EXISTS anime_locked;
+DROP TABLE IF EXISTS anime_aliases;
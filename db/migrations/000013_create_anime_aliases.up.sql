@@ -0,0 +1,15 @@
+-- File: 000013_create_anime_aliases.up.sql
+-- Judul alternatif anime dan penguncian anime playlist agar keputusan admin (merge/split) tidak ditimpa worker
+
+CREATE TABLE IF NOT EXISTS anime_aliases (
+    alias_id SERIAL PRIMARY KEY,
+    anime_id INT NOT NULL,
+    alias VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (anime_id) REFERENCES animes(anime_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_anime_aliases_anime_id ON anime_aliases(anime_id);
+
+-- TRUE berarti anime_id playlist ditentukan admin dan tidak diubah worker
+ALTER TABLE playlists ADD COLUMN IF NOT EXISTS anime_locked BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_playlists_season_id;
+ALTER TABLE playlists DROP COLUMN IF EXISTS season_id;
+DROP TABLE IF EXISTS seasons;
@@ -0,0 +1,18 @@
+-- File: 000014_create_seasons.up.sql
+-- Season dan cour/part sebuah anime, diambil dari judul playlist, agar episode Season 1 dan Season 2 tidak tercampur
+
+CREATE TABLE IF NOT EXISTS seasons (
+    season_id SERIAL PRIMARY KEY,
+    anime_id INT NOT NULL,
+    number INT NOT NULL DEFAULT 1,
+    -- 0 berarti seluruh season; 1, 2, ... untuk cour/part
+    part INT NOT NULL DEFAULT 0,
+    label VARCHAR(100) NOT NULL,
+    UNIQUE (anime_id, number, part),
+    FOREIGN KEY (anime_id) REFERENCES animes(anime_id) ON DELETE CASCADE
+);
+
+-- Diisi worker: playlist lama di-backfill dari judulnya (backfillSeasons) di awal sinkronisasi berikutnya
+ALTER TABLE playlists ADD COLUMN IF NOT EXISTS season_id INT REFERENCES seasons(season_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_playlists_season_id ON playlists(season_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS anime_view_snapshots;
+DROP TABLE IF EXISTS episode_view_snapshots;
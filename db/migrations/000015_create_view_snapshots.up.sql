@@ -0,0 +1,22 @@
+-- File: 000015_create_view_snapshots.up.sql
+-- Riwayat jumlah views per episode dan per anime, dicatat setiap task views berjalan, untuk menghitung tren 24 jam, 7 hari dan 30 hari
+
+CREATE TABLE IF NOT EXISTS episode_view_snapshots (
+    video_id VARCHAR(255) NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL,
+    view_count BIGINT NOT NULL,
+    PRIMARY KEY (video_id, captured_at),
+    FOREIGN KEY (video_id) REFERENCES episodes(video_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS anime_view_snapshots (
+    anime_id INT NOT NULL,
+    captured_at TIMESTAMPTZ NOT NULL,
+    -- total_view_count anime saat snapshot diambil
+    view_count BIGINT NOT NULL,
+    PRIMARY KEY (anime_id, captured_at),
+    FOREIGN KEY (anime_id) REFERENCES animes(anime_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_episode_view_snapshots_captured_at ON episode_view_snapshots(captured_at);
+CREATE INDEX IF NOT EXISTS idx_anime_view_snapshots_captured_at ON anime_view_snapshots(captured_at);
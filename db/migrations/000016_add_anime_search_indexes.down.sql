@@ -0,0 +1,5 @@
+-- Extension pg_trgm berlaku untuk seluruh database dan bisa saja sudah ada atau dipakai objek lain, jadi tidak di-drop
+DROP INDEX IF EXISTS idx_anime_aliases_alias_trgm;
+DROP INDEX IF EXISTS idx_animes_title_trgm;
+DROP INDEX IF EXISTS idx_anime_aliases_search_document;
+DROP INDEX IF EXISTS idx_animes_search_document;
@@ -0,0 +1,13 @@
+-- File: 000016_add_anime_search_indexes.up.sql
+-- Indeks pencarian anime: full-text atas judul, alias dan sinopsis, serta trigram atas judul dan alias agar pencarian tahan salah ketik
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Ekspresi indeks harus sama persis dengan yang dipakai query pencarian di internal/core/database
+CREATE INDEX IF NOT EXISTS idx_animes_search_document ON animes
+    USING GIN ((setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', COALESCE(synopsis, '')), 'D')));
+CREATE INDEX IF NOT EXISTS idx_anime_aliases_search_document ON anime_aliases
+    USING GIN (to_tsvector('simple', alias));
+
+CREATE INDEX IF NOT EXISTS idx_animes_title_trgm ON animes USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_anime_aliases_alias_trgm ON anime_aliases USING GIN (alias gin_trgm_ops);
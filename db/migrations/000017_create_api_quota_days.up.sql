@@ -0,0 +1,11 @@
+-- File: 000017_create_api_quota_days.up.sql
+-- Total pemakaian quota per hari dalam satu baris, agar budget harian bisa dicek dan ditambah dengan satu upsert bersyarat
+
+CREATE TABLE IF NOT EXISTS api_quota_days (
+    usage_date DATE PRIMARY KEY,
+    units BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO api_quota_days (usage_date, units)
+SELECT usage_date, SUM(units) FROM api_quota_usage GROUP BY usage_date
+ON CONFLICT (usage_date) DO UPDATE SET units = EXCLUDED.units;
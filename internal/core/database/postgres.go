@@ -2,10 +2,11 @@ package database
 
 import (
 	"alyo/internal/core/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"net/url"
 	"strings"
 	"time"
 
@@ -13,31 +14,88 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrNotFound dikembalikan saat baris yang akan diubah tidak ada.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict dikembalikan saat perubahan bentrok dengan data lain, misalnya
+// judul atau alias yang sudah dipakai anime lain.
+var ErrConflict = errors.New("conflict")
+
+// ErrLeaseLost dikembalikan saat lease sudah kedaluwarsa dan diambil instance lain.
+var ErrLeaseLost = errors.New("lease lost")
+
 // GetAnimesParams adalah struct untuk parameter pencarian, filter, dan sort.
 type GetAnimesParams struct {
 	Search string
 	Sort   string
 	Limit  int
 	Offset int
+
+	// IncludeUnavailable ikut menampilkan anime yang semua episodenya sudah tidak tersedia.
+	IncludeUnavailable bool
 }
 
 // Store mendefinisikan semua fungsi untuk berinteraksi dengan database.
 type Store interface {
-	UpsertChannel(channel models.Channel) error
-	FindAnimeByTitle(title string) (*models.Anime, error)
-	UpsertAnime(anime models.Anime) (int, error)
-	UpsertPlaylist(playlist models.Playlist) error
-	UpsertEpisode(episode models.Episode) error
-	GetAllAnimes() ([]models.Anime, error)
-	GetAnimeWithEpisodes(animeID int) (*models.AnimeWithEpisodes, error)
-	GetAnimes(params GetAnimesParams) ([]models.Anime, error)
-	CountAnimes(params GetAnimesParams) (int, error)
-	UpdateAnimeLastUpdated(animeID int, timestamp time.Time) error
-	UpdateAnimeThumbnailURL(animeID int, url string) error
-	GetAnimeViewData(animeID int) (totalViews int64, err error)
-	UpdateAnimeViewData(animeID int, totalViews int64, weeklyIncrease int64) error
-	GetTopWeeklyAnimes() ([]models.Anime, error)
-	GetAllChannelsMap() (map[string]models.Channel, error)
+	UpsertChannel(ctx context.Context, channel models.Channel) error
+	FindAnimeByTitle(ctx context.Context, title string) (*models.Anime, error)
+	UpsertAnime(ctx context.Context, anime models.Anime) (int, error)
+	UpsertSeason(ctx context.Context, season models.Season) (int, error)
+	UpsertPlaylist(ctx context.Context, playlist models.Playlist) error
+	GetPlaylist(ctx context.Context, playlistID string) (*models.Playlist, error)
+	GetPlaylistsWithoutSeason(ctx context.Context) ([]models.Playlist, error)
+	GetEpisode(ctx context.Context, videoID string) (*models.Episode, error)
+	GetEpisodesForPlaylist(ctx context.Context, playlistID string) ([]models.Episode, error)
+	UpsertEpisode(ctx context.Context, episode models.Episode) (inserted bool, err error)
+	MarkEpisodeUnavailable(ctx context.Context, videoID string, reason string) error
+	MarkMissingEpisodesUnavailable(ctx context.Context, playlistID string, presentVideoIDs []string) (int64, error)
+	GetAllAnimes(ctx context.Context) ([]models.Anime, error)
+	ListAnimeTitles(ctx context.Context) ([]models.AnimeTitle, error)
+	GetMatchReviewForPlaylist(ctx context.Context, playlistID string) (*models.AnimeMatchReview, error)
+	CreateMatchReview(ctx context.Context, review models.AnimeMatchReview) error
+	GetMatchReviews(ctx context.Context, status string, limit int) ([]models.AnimeMatchReview, error)
+	ResolveMatchReview(ctx context.Context, reviewID int64, status string, animeID *int) (*models.AnimeMatchReview, error)
+	GetAnimeAliases(ctx context.Context, animeID int) ([]models.AnimeAlias, error)
+	AddAnimeAlias(ctx context.Context, animeID int, alias string) (*models.AnimeAlias, error)
+	DeleteAnimeAlias(ctx context.Context, animeID int, aliasID int64) error
+	MergeAnimes(ctx context.Context, sourceID, targetID int) error
+	SplitPlaylist(ctx context.Context, playlistID string, title string) (animeID int, err error)
+	GetAnimeWithEpisodes(ctx context.Context, animeID int, includeUnavailable bool) (*models.AnimeWithEpisodes, error)
+	GetAnimes(ctx context.Context, params GetAnimesParams) ([]models.Anime, error)
+	CountAnimes(ctx context.Context, params GetAnimesParams) (int, error)
+	SuggestAnimes(ctx context.Context, query string, limit int) ([]models.AnimeSuggestion, error)
+	GetAvailableEpisodeIDs(ctx context.Context, channelIDs []string) ([]string, error)
+	UpdateEpisodeStatistics(ctx context.Context, stats []models.EpisodeStatistics) (int64, error)
+	RecomputeAnimeAggregates(ctx context.Context, animeIDs []int) (int64, error)
+	SaveViewSnapshots(ctx context.Context, stats []models.EpisodeStatistics) error
+	GetTrendingAnimes(ctx context.Context, window time.Duration, limit int) ([]models.Anime, error)
+	GetAnimeViewSeries(ctx context.Context, animeID int, bucket string, since time.Time) ([]models.ViewPoint, error)
+	GetEpisodeViewSeries(ctx context.Context, videoID string, bucket string, since time.Time) ([]models.ViewPoint, error)
+	DownsampleViewSnapshots(ctx context.Context, keepHourly, keepDaily time.Duration) (int64, error)
+	GetAllChannelsMap(ctx context.Context) (map[string]models.Channel, error)
+	ListChannels(ctx context.Context) ([]models.Channel, error)
+	GetEnabledChannels(ctx context.Context) ([]models.Channel, error)
+	AddChannel(ctx context.Context, channel models.Channel) error
+	SetChannelEnabled(ctx context.Context, channelID string, enabled bool) error
+	GetPlaylistSyncState(ctx context.Context, playlistID string) (*models.PlaylistSyncState, error)
+	SavePlaylistSyncState(ctx context.Context, state models.PlaylistSyncState) error
+	CreateSyncRun(ctx context.Context, task string, startedAt time.Time) (int64, error)
+	FinishSyncRun(ctx context.Context, run models.SyncRun) error
+	SaveSyncRunChannel(ctx context.Context, channel models.SyncRunChannel) error
+	SaveSyncRunPlaylist(ctx context.Context, playlist models.SyncRunPlaylist) error
+	GetSyncRuns(ctx context.Context, limit int) ([]models.SyncRun, error)
+	GetSyncRun(ctx context.Context, runID int64) (*models.SyncRun, error)
+	AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (lease models.Lease, acquired bool, err error)
+	RenewLease(ctx context.Context, name string, holder string, ttl time.Duration) error
+	ReleaseLease(ctx context.Context, name string, holder string) error
+	ReserveQuota(ctx context.Context, day string, endpoint string, keyLabel string, units int, budget int64) (used int64, reserved bool, err error)
+	GetQuotaUsage(ctx context.Context, day string) (map[string]int64, error)
+	GetQuotaUsageByKey(ctx context.Context, day string) (map[string]int64, error)
+	GetCachedResponse(ctx context.Context, resourceKey string) (etag string, body []byte, err error)
+	SaveCachedResponse(ctx context.Context, resourceKey string, etag string, body []byte) error
+	DeleteCachedResponse(ctx context.Context, resourceKey string) error
+	PruneCachedResponses(ctx context.Context, olderThan time.Duration) (int64, error)
+	Close() error
 }
 
 // DBStore adalah implementasi dari Store menggunakan PostgreSQL.
@@ -55,18 +113,28 @@ func NewDBStore(databaseURL string) (Store, error) {
 	return &DBStore{db: db}, nil
 }
 
+// Close menutup pool koneksi database. Dipanggil sekali saat aplikasi berhenti.
+func (s *DBStore) Close() error {
+	return s.db.Close()
+}
+
 // UpsertChannel menyisipkan channel baru atau memperbarui yang sudah ada.
-func (s *DBStore) UpsertChannel(channel models.Channel) error {
+func (s *DBStore) UpsertChannel(ctx context.Context, channel models.Channel) error {
 	query := `INSERT INTO channels (channel_id, name, url, profile_picture_url) VALUES ($1, $2, $3, $4) ON CONFLICT (channel_id) DO UPDATE SET name = EXCLUDED.name, url = EXCLUDED.url, profile_picture_url = EXCLUDED.profile_picture_url;`
-	_, err := s.db.Exec(query, channel.ID, channel.Name, channel.URL, channel.ProfilePictureURL)
+	_, err := s.db.ExecContext(ctx, query, channel.ID, channel.Name, channel.URL, channel.ProfilePictureURL)
 	return err
 }
 
-// FindAnimeByTitle mencari anime berdasarkan judulnya.
-func (s *DBStore) FindAnimeByTitle(title string) (*models.Anime, error) {
+// FindAnimeByTitle mencari anime berdasarkan judul atau salah satu aliasnya.
+// Judul yang sama persis didahulukan daripada alias.
+func (s *DBStore) FindAnimeByTitle(ctx context.Context, title string) (*models.Anime, error) {
 	var anime models.Anime
-	query := `SELECT * FROM animes WHERE title = $1`
-	err := s.db.Get(&anime, query, title)
+	query := `
+		SELECT a.* FROM animes a
+		WHERE a.title = $1 OR a.anime_id = (SELECT anime_id FROM anime_aliases WHERE alias = $1)
+		ORDER BY a.title = $1 DESC
+		LIMIT 1`
+	err := s.db.GetContext(ctx, &anime, query, title)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -74,47 +142,167 @@ func (s *DBStore) FindAnimeByTitle(title string) (*models.Anime, error) {
 }
 
 // UpsertAnime menyisipkan anime baru atau memperbarui yang sudah ada.
-func (s *DBStore) UpsertAnime(anime models.Anime) (int, error) {
+func (s *DBStore) UpsertAnime(ctx context.Context, anime models.Anime) (int, error) {
 	var animeID int
 	query := `INSERT INTO animes (title, synopsis) VALUES ($1, $2) ON CONFLICT (title) DO UPDATE SET synopsis = EXCLUDED.synopsis RETURNING anime_id;`
-	err := s.db.QueryRowx(query, anime.Title, anime.Synopsis).Scan(&animeID)
+	err := s.db.QueryRowxContext(ctx, query, anime.Title, anime.Synopsis).Scan(&animeID)
 	return animeID, err
 }
 
+// UpsertSeason menyisipkan season anime jika belum ada (berdasarkan nomor
+// season dan part) lalu mengembalikan ID-nya.
+func (s *DBStore) UpsertSeason(ctx context.Context, season models.Season) (int, error) {
+	var seasonID int
+	query := `INSERT INTO seasons (anime_id, number, part, label) VALUES ($1, $2, $3, $4) ON CONFLICT (anime_id, number, part) DO UPDATE SET label = EXCLUDED.label RETURNING season_id;`
+	err := s.db.QueryRowxContext(ctx, query, season.AnimeID, season.Number, season.Part, season.Label).Scan(&seasonID)
+	return seasonID, err
+}
+
 // UpsertPlaylist menyisipkan playlist baru atau memperbarui yang sudah ada.
-func (s *DBStore) UpsertPlaylist(playlist models.Playlist) error {
-	query := `INSERT INTO playlists (playlist_id, channel_id, anime_id, title, description, language) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (playlist_id) DO UPDATE SET channel_id = EXCLUDED.channel_id, anime_id = EXCLUDED.anime_id, title = EXCLUDED.title, description = EXCLUDED.description, language = EXCLUDED.language;`
-	_, err := s.db.Exec(query, playlist.ID, playlist.ChannelID, playlist.AnimeID, playlist.Title, playlist.Description, playlist.Language)
+// Anime playlist yang dikunci admin (anime_locked) tidak diubah, begitu juga
+// season-nya jika season baru milik anime lain.
+func (s *DBStore) UpsertPlaylist(ctx context.Context, playlist models.Playlist) error {
+	query := `INSERT INTO playlists (playlist_id, channel_id, anime_id, season_id, title, description, language) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (playlist_id) DO UPDATE SET channel_id = EXCLUDED.channel_id, anime_id = CASE WHEN playlists.anime_locked THEN playlists.anime_id ELSE EXCLUDED.anime_id END, season_id = CASE WHEN playlists.anime_locked AND playlists.anime_id IS DISTINCT FROM EXCLUDED.anime_id THEN playlists.season_id ELSE EXCLUDED.season_id END, title = EXCLUDED.title, description = EXCLUDED.description, language = EXCLUDED.language;`
+	_, err := s.db.ExecContext(ctx, query, playlist.ID, playlist.ChannelID, playlist.AnimeID, playlist.SeasonID, playlist.Title, playlist.Description, playlist.Language)
 	return err
 }
 
-// UpsertEpisode menyisipkan episode baru atau memperbarui yang sudah ada.
-func (s *DBStore) UpsertEpisode(episode models.Episode) error {
-	query := `INSERT INTO episodes (video_id, playlist_id, title, episode_number, published_at, thumbnail_url, view_count) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (video_id) DO UPDATE SET playlist_id = EXCLUDED.playlist_id, title = EXCLUDED.title, episode_number = EXCLUDED.episode_number, published_at = EXCLUDED.published_at, thumbnail_url = EXCLUDED.thumbnail_url, view_count = EXCLUDED.view_count;`
-	_, err := s.db.Exec(query, episode.VideoID, episode.PlaylistID, episode.Title, episode.EpisodeNumber, episode.PublishedAt, episode.ThumbnailURL, episode.ViewCount)
+// GetPlaylist mengambil satu playlist, atau nil jika belum tersimpan.
+func (s *DBStore) GetPlaylist(ctx context.Context, playlistID string) (*models.Playlist, error) {
+	var playlist models.Playlist
+	query := `SELECT * FROM playlists WHERE playlist_id = $1`
+	err := s.db.GetContext(ctx, &playlist, query, playlistID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+// GetPlaylistsWithoutSeason mengambil playlist yang sudah punya anime tetapi
+// belum punya season, yaitu playlist yang tersimpan sebelum tabel seasons ada.
+func (s *DBStore) GetPlaylistsWithoutSeason(ctx context.Context) ([]models.Playlist, error) {
+	var playlists []models.Playlist
+	query := `SELECT * FROM playlists WHERE anime_id IS NOT NULL AND season_id IS NULL ORDER BY playlist_id`
+	err := s.db.SelectContext(ctx, &playlists, query)
+	return playlists, err
+}
+
+// GetEpisode mengambil satu episode, atau nil jika belum tersimpan.
+func (s *DBStore) GetEpisode(ctx context.Context, videoID string) (*models.Episode, error) {
+	var episode models.Episode
+	query := `SELECT * FROM episodes WHERE video_id = $1`
+	err := s.db.GetContext(ctx, &episode, query, videoID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &episode, nil
+}
+
+// GetEpisodesForPlaylist mengambil semua episode sebuah playlist, termasuk yang tidak tersedia.
+func (s *DBStore) GetEpisodesForPlaylist(ctx context.Context, playlistID string) ([]models.Episode, error) {
+	var episodes []models.Episode
+	query := `SELECT * FROM episodes WHERE playlist_id = $1 ORDER BY episode_number ASC, published_at ASC`
+	err := s.db.SelectContext(ctx, &episodes, query, playlistID)
+	return episodes, err
+}
+
+// UpsertEpisode menyisipkan episode baru atau memperbarui yang sudah ada, dan
+// melaporkan apakah episode baru disisipkan. Episode yang muncul lagi di
+// playlist otomatis tersedia kembali.
+func (s *DBStore) UpsertEpisode(ctx context.Context, episode models.Episode) (bool, error) {
+	query := `INSERT INTO episodes (video_id, playlist_id, title, episode_number, published_at, thumbnail_url, view_count, like_count, comment_count, duration_seconds, privacy_status, embeddable, region_allowed, region_blocked) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) ON CONFLICT (video_id) DO UPDATE SET playlist_id = EXCLUDED.playlist_id, title = EXCLUDED.title, episode_number = EXCLUDED.episode_number, published_at = EXCLUDED.published_at, thumbnail_url = EXCLUDED.thumbnail_url, view_count = EXCLUDED.view_count, like_count = EXCLUDED.like_count, comment_count = EXCLUDED.comment_count, duration_seconds = EXCLUDED.duration_seconds, privacy_status = EXCLUDED.privacy_status, embeddable = EXCLUDED.embeddable, region_allowed = EXCLUDED.region_allowed, region_blocked = EXCLUDED.region_blocked, unavailable_at = NULL, unavailable_reason = NULL RETURNING (xmax = 0) AS inserted;`
+	// xmax bernilai 0 hanya untuk baris yang baru disisipkan, bukan yang diperbarui lewat ON CONFLICT.
+	var inserted bool
+	err := s.db.QueryRowxContext(ctx, query, episode.VideoID, episode.PlaylistID, episode.Title, episode.EpisodeNumber, episode.PublishedAt, episode.ThumbnailURL, episode.ViewCount, episode.LikeCount, episode.CommentCount, episode.DurationSeconds, episode.PrivacyStatus, episode.Embeddable, episode.RegionAllowed, episode.RegionBlocked).Scan(&inserted)
+	return inserted, err
+}
+
+// MarkEpisodeUnavailable menandai satu episode tidak tersedia. Episode yang
+// sudah ditandai sebelumnya tetap memakai timestamp lamanya.
+func (s *DBStore) MarkEpisodeUnavailable(ctx context.Context, videoID string, reason string) error {
+	query := `UPDATE episodes SET unavailable_at = NOW(), unavailable_reason = $2 WHERE video_id = $1 AND unavailable_at IS NULL`
+	_, err := s.db.ExecContext(ctx, query, videoID, reason)
 	return err
 }
 
+// MarkMissingEpisodesUnavailable menandai episode milik playlist yang tidak ada
+// di presentVideoIDs sebagai 'removed' dan mengembalikan jumlah episode yang baru ditandai.
+func (s *DBStore) MarkMissingEpisodesUnavailable(ctx context.Context, playlistID string, presentVideoIDs []string) (int64, error) {
+	if presentVideoIDs == nil {
+		presentVideoIDs = []string{}
+	}
+	query := `UPDATE episodes SET unavailable_at = NOW(), unavailable_reason = $3 WHERE playlist_id = $1 AND unavailable_at IS NULL AND NOT (video_id = ANY($2))`
+	res, err := s.db.ExecContext(ctx, query, playlistID, presentVideoIDs, models.EpisodeRemoved)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// availableEpisodeCondition menyaring anime yang masih punya minimal satu episode tersedia.
+const availableEpisodeCondition = `EXISTS (SELECT 1 FROM episodes e JOIN playlists ep ON e.playlist_id = ep.playlist_id WHERE ep.anime_id = a.anime_id AND e.unavailable_at IS NULL)`
+
+// animeSearchDocument adalah dokumen full-text anime: judul berbobot A dan
+// sinopsis berbobot D. Ekspresinya harus sama persis dengan indeks
+// idx_animes_search_document agar indeks itu terpakai.
+const animeSearchDocument = `setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', COALESCE(synopsis, '')), 'D')`
+
+// likeEscaper meloloskan karakter khusus LIKE agar kata kunci dicocokkan apa adanya.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchMatches adalah CTE matches berisi anime yang cocok dengan kata kunci $1
+// (dan $2, pola ILIKE dari searchPattern) beserta skor relevansinya. Sebuah anime cocok jika judul, alias atau
+// sinopsisnya cocok secara full-text, atau judul/aliasnya mirip secara trigram
+// (tahan salah ketik, misalnya "mushoku tensi") atau mengandung kata kunci.
+// Skornya adalah ts_rank ditambah word_similarity judul atau alias terbaik.
+const searchMatches = `
+	WITH matches AS (
+		SELECT anime_id, MAX(score) AS score FROM (
+			SELECT anime_id, ts_rank(` + animeSearchDocument + `, websearch_to_tsquery('simple', $1)) + word_similarity($1, title) AS score
+			FROM animes
+			WHERE ` + animeSearchDocument + ` @@ websearch_to_tsquery('simple', $1)
+				OR $1 <% title OR title ILIKE $2 ESCAPE '\'
+			UNION ALL
+			SELECT anime_id, ts_rank(to_tsvector('simple', alias), websearch_to_tsquery('simple', $1)) + word_similarity($1, alias)
+			FROM anime_aliases
+			WHERE to_tsvector('simple', alias) @@ websearch_to_tsquery('simple', $1)
+				OR $1 <% alias OR alias ILIKE $2 ESCAPE '\'
+		) ranked
+		GROUP BY anime_id
+	)`
+
+// searchPattern mengembalikan pola ILIKE yang mencocokkan judul yang mengandung search.
+func searchPattern(search string) string {
+	return "%" + likeEscaper.Replace(search) + "%"
+}
+
 // CountAnimes menghitung total anime yang cocok dengan kriteria pencarian.
-func (s *DBStore) CountAnimes(params GetAnimesParams) (int, error) {
+func (s *DBStore) CountAnimes(ctx context.Context, params GetAnimesParams) (int, error) {
 	var count int
 	baseQuery := `SELECT COUNT(DISTINCT a.anime_id) FROM animes a JOIN playlists p ON a.anime_id = p.anime_id`
 	conditions := []string{"a.thumbnail_url IS NOT NULL"}
 	var args []interface{}
-	argID := 1
 	if params.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("a.title ILIKE $%d", argID))
-		args = append(args, "%"+params.Search+"%")
-		argID++
+		baseQuery = searchMatches + baseQuery + ` JOIN matches m ON m.anime_id = a.anime_id`
+		args = append(args, params.Search, searchPattern(params.Search))
+	}
+	if !params.IncludeUnavailable {
+		conditions = append(conditions, availableEpisodeCondition)
 	}
 	whereClause := " WHERE " + strings.Join(conditions, " AND ")
 	finalQuery := baseQuery + whereClause
-	err := s.db.Get(&count, finalQuery, args...)
+	err := s.db.GetContext(ctx, &count, finalQuery, args...)
 	return count, err
 }
 
 // GetAnimes dioptimalkan dengan GROUP BY dan kini mendukung pagination.
-func (s *DBStore) GetAnimes(params GetAnimesParams) ([]models.Anime, error) {
+func (s *DBStore) GetAnimes(ctx context.Context, params GetAnimesParams) ([]models.Anime, error) {
 	var animes []models.Anime
 	baseQuery := `
 		SELECT
@@ -127,16 +315,26 @@ func (s *DBStore) GetAnimes(params GetAnimesParams) ([]models.Anime, error) {
 	`
 	conditions := []string{"a.thumbnail_url IS NOT NULL"}
 	var args []interface{}
-	argID := 1
 	if params.Search != "" {
-		conditions = append(conditions, fmt.Sprintf("a.title ILIKE $%d", argID))
-		args = append(args, "%"+params.Search+"%")
-		argID++
+		baseQuery = searchMatches + baseQuery + ` JOIN matches m ON m.anime_id = a.anime_id`
+		args = append(args, params.Search, searchPattern(params.Search))
+	}
+	if !params.IncludeUnavailable {
+		conditions = append(conditions, availableEpisodeCondition)
 	}
 	whereClause := " WHERE " + strings.Join(conditions, " AND ")
 	groupByClause := " GROUP BY a.anime_id"
 	orderBy := " ORDER BY last_updated DESC NULLS LAST"
-	switch params.Sort {
+	// Hasil pencarian diurutkan dari yang paling relevan kecuali sort diminta.
+	sort := params.Sort
+	if sort == "" && params.Search != "" {
+		sort = "relevance"
+	}
+	switch sort {
+	case "relevance":
+		if params.Search != "" {
+			orderBy = " ORDER BY MAX(m.score) DESC, total_view_count DESC NULLS LAST"
+		}
 	case "name_asc":
 		orderBy = " ORDER BY title ASC"
 	case "name_desc":
@@ -150,62 +348,555 @@ func (s *DBStore) GetAnimes(params GetAnimesParams) ([]models.Anime, error) {
 	}
 	paginationClause := fmt.Sprintf(" LIMIT %d OFFSET %d", params.Limit, params.Offset)
 	finalQuery := baseQuery + whereClause + groupByClause + orderBy + paginationClause
-	err := s.db.Select(&animes, finalQuery, args...)
+	err := s.db.SelectContext(ctx, &animes, finalQuery, args...)
 	return animes, err
 }
 
-// UpdateAnimeLastUpdated memperbarui timestamp anime.
-func (s *DBStore) UpdateAnimeLastUpdated(animeID int, timestamp time.Time) error {
-	query := `UPDATE animes SET last_updated = $1 WHERE anime_id = $2`
-	_, err := s.db.Exec(query, timestamp, animeID)
-	return err
+// SuggestAnimes mengambil sedikit anime yang judul atau aliasnya cocok dengan
+// kata kunci yang sedang diketik, untuk autocomplete. Judul yang diawali kata
+// kunci diurutkan paling atas, disusul yang mengandungnya, lalu yang mirip
+// secara trigram; sisanya diurutkan dari total views terbanyak.
+func (s *DBStore) SuggestAnimes(ctx context.Context, query string, limit int) ([]models.AnimeSuggestion, error) {
+	suggestions := []models.AnimeSuggestion{}
+	q := `
+		WITH matches AS (
+			SELECT anime_id, MAX(score) AS score FROM (
+				SELECT anime_id, (title ILIKE $2 ESCAPE '\')::int * 2 + (title ILIKE $3 ESCAPE '\')::int + word_similarity($1, title) AS score
+				FROM animes
+				WHERE title ILIKE $3 ESCAPE '\' OR $1 <% title
+				UNION ALL
+				SELECT anime_id, (alias ILIKE $2 ESCAPE '\')::int * 2 + (alias ILIKE $3 ESCAPE '\')::int + word_similarity($1, alias)
+				FROM anime_aliases
+				WHERE alias ILIKE $3 ESCAPE '\' OR $1 <% alias
+			) ranked
+			GROUP BY anime_id
+		)
+		SELECT a.anime_id, a.title, a.thumbnail_url
+		FROM matches m
+		JOIN animes a ON a.anime_id = m.anime_id
+		WHERE a.thumbnail_url IS NOT NULL AND ` + availableEpisodeCondition + `
+		ORDER BY m.score DESC, a.total_view_count DESC
+		LIMIT $4`
+	prefix := likeEscaper.Replace(query) + "%"
+	err := s.db.SelectContext(ctx, &suggestions, q, query, prefix, searchPattern(query), limit)
+	return suggestions, err
+}
+
+// ListAnimeTitles mengambil judul dan alias semua anime. Anime dengan alias
+// muncul lebih dari sekali.
+func (s *DBStore) ListAnimeTitles(ctx context.Context) ([]models.AnimeTitle, error) {
+	var animeTitles []models.AnimeTitle
+	query := `SELECT anime_id, title FROM animes UNION ALL SELECT anime_id, alias FROM anime_aliases ORDER BY anime_id, title`
+	err := s.db.SelectContext(ctx, &animeTitles, query)
+	return animeTitles, err
+}
+
+// GetAnimeAliases mengambil semua alias sebuah anime.
+func (s *DBStore) GetAnimeAliases(ctx context.Context, animeID int) ([]models.AnimeAlias, error) {
+	aliases := []models.AnimeAlias{}
+	query := `SELECT * FROM anime_aliases WHERE anime_id = $1 ORDER BY alias`
+	err := s.db.SelectContext(ctx, &aliases, query, animeID)
+	return aliases, err
 }
 
-// UpdateAnimeThumbnailURL memperbarui thumbnail anime jika belum ada dan URL valid.
-func (s *DBStore) UpdateAnimeThumbnailURL(animeID int, newURL string) error {
-	if newURL == "" {
-		return nil
+// AddAnimeAlias menambahkan alias ke sebuah anime. Mengembalikan ErrNotFound
+// jika anime tidak ada, dan ErrConflict jika alias sudah menjadi judul anime
+// atau alias anime lain.
+func (s *DBStore) AddAnimeAlias(ctx context.Context, animeID int, alias string) (*models.AnimeAlias, error) {
+	var a models.AnimeAlias
+	query := `
+		INSERT INTO anime_aliases (anime_id, alias)
+		SELECT anime_id, $2 FROM animes
+		WHERE anime_id = $1 AND NOT EXISTS (SELECT 1 FROM animes WHERE title = $2)
+		ON CONFLICT (alias) DO NOTHING
+		RETURNING *`
+	err := s.db.GetContext(ctx, &a, query, animeID, alias)
+	if err == sql.ErrNoRows {
+		var exists bool
+		if err := s.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM animes WHERE anime_id = $1)`, animeID); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrNotFound
+		}
+		return nil, ErrConflict
 	}
-	_, err := url.ParseRequestURI(newURL)
 	if err != nil {
-		log.Printf("WARN: Invalid URL provided for thumbnail, skipping update: %s", newURL)
-		return nil
+		return nil, err
 	}
-	query := `UPDATE animes SET thumbnail_url = $1 WHERE anime_id = $2 AND thumbnail_url IS NULL`
-	_, err = s.db.Exec(query, newURL, animeID)
-	return err
+	return &a, nil
+}
+
+// DeleteAnimeAlias menghapus satu alias anime. Mengembalikan ErrNotFound jika
+// alias tidak ada atau milik anime lain.
+func (s *DBStore) DeleteAnimeAlias(ctx context.Context, animeID int, aliasID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM anime_aliases WHERE alias_id = $1 AND anime_id = $2`, aliasID, animeID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MergeAnimes menggabungkan anime sourceID ke targetID dalam satu transaksi:
+// semua playlist dipindahkan dan dikunci ke season target yang bernomor sama,
+// judul serta alias source menjadi alias target (sehingga playlist baru dengan
+// judul itu ikut ke target), review pencocokan diarahkan ke target, snapshot
+// views source dijumlahkan ke target, agregat target dihitung ulang, lalu
+// source dihapus. Mengembalikan ErrNotFound jika salah satu anime tidak ada.
+func (s *DBStore) MergeAnimes(ctx context.Context, sourceID, targetID int) error {
+	if sourceID == targetID {
+		return ErrConflict
+	}
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked []int
+	query := `SELECT anime_id FROM animes WHERE anime_id IN ($1, $2) ORDER BY anime_id FOR UPDATE`
+	if err := tx.SelectContext(ctx, &locked, query, sourceID, targetID); err != nil {
+		return err
+	}
+	if len(locked) != 2 {
+		return ErrNotFound
+	}
+
+	statements := []string{
+		`INSERT INTO seasons (anime_id, number, part, label) SELECT $2::int, number, part, label FROM seasons WHERE anime_id = $1 ON CONFLICT (anime_id, number, part) DO NOTHING`,
+		`UPDATE playlists p SET season_id = t.season_id FROM seasons src JOIN seasons t ON t.anime_id = $2 AND t.number = src.number AND t.part = src.part WHERE src.anime_id = $1 AND p.season_id = src.season_id`,
+		`UPDATE playlists SET anime_id = $2, anime_locked = TRUE WHERE anime_id = $1`,
+		`UPDATE anime_aliases SET anime_id = $2 WHERE anime_id = $1`,
+		`INSERT INTO anime_aliases (anime_id, alias) SELECT $2::int, title FROM animes WHERE anime_id = $1 ON CONFLICT (alias) DO UPDATE SET anime_id = EXCLUDED.anime_id`,
+		`UPDATE anime_match_reviews SET candidate_anime_id = $2 WHERE candidate_anime_id = $1`,
+		`UPDATE anime_match_reviews SET resolved_anime_id = $2 WHERE resolved_anime_id = $1`,
+		`INSERT INTO anime_view_snapshots (anime_id, captured_at, view_count) SELECT $2::int, captured_at, view_count FROM anime_view_snapshots WHERE anime_id = $1 ON CONFLICT (anime_id, captured_at) DO UPDATE SET view_count = anime_view_snapshots.view_count + EXCLUDED.view_count`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, sourceID, targetID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM animes WHERE anime_id = $1`, sourceID); err != nil {
+		return err
+	}
+	if _, err := recomputeAnimeAggregates(ctx, tx, []int{targetID}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// GetAnimeViewData mengambil total view count saat ini dari database.
-func (s *DBStore) GetAnimeViewData(animeID int) (totalViews int64, err error) {
-	query := `SELECT total_view_count FROM animes WHERE anime_id = $1`
-	err = s.db.Get(&totalViews, query, animeID)
+// SplitPlaylist memindahkan playlist dari anime-nya ke anime baru berjudul
+// title dalam satu transaksi, lalu mengunci playlist agar worker tidak
+// menggabungkannya lagi. Jika title adalah alias anime lama (misalnya sisa
+// merge), alias itu dihapus. Total kedua anime dihitung ulang. Mengembalikan
+// ErrNotFound jika playlist tidak ada, dan ErrConflict jika title sudah menjadi
+// judul atau alias anime lain.
+func (s *DBStore) SplitPlaylist(ctx context.Context, playlistID string, title string) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var playlist models.Playlist
+	err = tx.GetContext(ctx, &playlist, `SELECT * FROM playlists WHERE playlist_id = $1 FOR UPDATE`, playlistID)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if playlist.AnimeID != nil {
+		_, err := tx.ExecContext(ctx, `DELETE FROM anime_aliases WHERE anime_id = $1 AND alias = $2`, *playlist.AnimeID, title)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var animeID int
+	query := `
+		INSERT INTO animes (title, synopsis)
+		SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM anime_aliases WHERE alias = $1)
+		ON CONFLICT (title) DO NOTHING
+		RETURNING anime_id`
+	err = tx.QueryRowxContext(ctx, query, title, playlist.Description).Scan(&animeID)
 	if err == sql.ErrNoRows {
+		return 0, ErrConflict
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Season playlist dipindahkan bersama playlist-nya ke anime baru.
+	var seasonID *int
+	if playlist.SeasonID != nil {
+		query := `INSERT INTO seasons (anime_id, number, part, label) SELECT $2::int, number, part, label FROM seasons WHERE season_id = $1 RETURNING season_id`
+		if err := tx.QueryRowxContext(ctx, query, *playlist.SeasonID, animeID).Scan(&seasonID); err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE playlists SET anime_id = $2, season_id = $3, anime_locked = TRUE WHERE playlist_id = $1`, playlistID, animeID, seasonID)
+	if err != nil {
+		return 0, err
+	}
+	if playlist.SeasonID != nil {
+		_, err := tx.ExecContext(ctx, `DELETE FROM seasons s WHERE season_id = $1 AND NOT EXISTS (SELECT 1 FROM playlists WHERE season_id = s.season_id)`, *playlist.SeasonID)
+		if err != nil {
+			return 0, err
+		}
+	}
+	// Snapshot views episode playlist ikut pindah agar tren kedua anime tidak melonjak.
+	query = `
+		WITH moved AS (
+			SELECT s.captured_at, SUM(s.view_count)::bigint AS view_count
+			FROM episode_view_snapshots s JOIN episodes e ON e.video_id = s.video_id
+			WHERE e.playlist_id = $1
+			GROUP BY s.captured_at
+		), old AS (
+			UPDATE anime_view_snapshots a SET view_count = a.view_count - m.view_count
+			FROM moved m
+			WHERE a.anime_id = $3 AND a.captured_at = m.captured_at
+		)
+		INSERT INTO anime_view_snapshots (anime_id, captured_at, view_count)
+		SELECT $2::int, captured_at, view_count FROM moved`
+	if _, err := tx.ExecContext(ctx, query, playlistID, animeID, playlist.AnimeID); err != nil {
+		return 0, err
+	}
+
+	affected := []int{animeID}
+	if playlist.AnimeID != nil {
+		affected = append(affected, *playlist.AnimeID)
+	}
+	if _, err := recomputeAnimeAggregates(ctx, tx, affected); err != nil {
+		return 0, err
+	}
+	return animeID, tx.Commit()
+}
+
+// RecomputeAnimeAggregates menghitung ulang agregat anime-anime tertentu (nil
+// berarti semua anime) dari seluruh episode di semua playlist-nya, di semua
+// channel dan bahasa, dalam satu transaksi. Mengembalikan jumlah anime yang berubah.
+func (s *DBStore) RecomputeAnimeAggregates(ctx context.Context, animeIDs []int) (int64, error) {
+	if animeIDs != nil && len(animeIDs) == 0 {
 		return 0, nil
 	}
-	return
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := recomputeAnimeAggregates(ctx, tx, animeIDs)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// recomputeAnimeAggregates menghitung ulang di dalam tx:
+//   - total_view_count: jumlah views semua episode yang masih tersedia,
+//   - weekly_view_increase: selisih total dengan snapshot 7 hari yang lalu,
+//   - last_updated: waktu terbit episode tersedia yang terbaru,
+//   - thumbnail_url: tetap, kecuali sudah bukan milik episode anime ini; jika
+//     begitu diganti thumbnail episode tersedia yang paling awal terbit.
+func recomputeAnimeAggregates(ctx context.Context, tx *sqlx.Tx, animeIDs []int) (int64, error) {
+	var filter interface{}
+	if animeIDs != nil {
+		filter = animeIDs
+	}
+	query := `
+		UPDATE animes a SET
+		    total_view_count = t.total,
+		    weekly_view_increase = t.total - COALESCE(t.baseline, t.total),
+		    last_updated = COALESCE(t.latest, a.last_updated),
+		    thumbnail_url = t.thumbnail
+		FROM (
+			SELECT v.anime_id,
+			       COALESCE(SUM(e.view_count) FILTER (WHERE e.unavailable_at IS NULL), 0)::bigint AS total,
+			       MAX(e.published_at) FILTER (WHERE e.unavailable_at IS NULL) AS latest,
+			       ` + viewBaseline + ` AS baseline,
+			       CASE
+			           WHEN bool_or(e.thumbnail_url = v.thumbnail_url) THEN v.thumbnail_url
+			           ELSE COALESCE((
+			               SELECT e2.thumbnail_url FROM episodes e2 JOIN playlists p2 ON p2.playlist_id = e2.playlist_id
+			               WHERE p2.anime_id = v.anime_id AND e2.unavailable_at IS NULL AND e2.thumbnail_url IS NOT NULL
+			               ORDER BY e2.published_at ASC LIMIT 1
+			           ), v.thumbnail_url)
+			       END AS thumbnail
+			FROM animes v
+			LEFT JOIN playlists p ON p.anime_id = v.anime_id
+			LEFT JOIN episodes e ON e.playlist_id = p.playlist_id
+			WHERE $2::int[] IS NULL OR v.anime_id = ANY($2)
+			GROUP BY v.anime_id
+		) t
+		WHERE a.anime_id = t.anime_id
+		  AND (a.total_view_count, a.weekly_view_increase, a.last_updated, a.thumbnail_url)
+		      IS DISTINCT FROM (t.total, t.total - COALESCE(t.baseline, t.total), COALESCE(t.latest, a.last_updated), t.thumbnail)`
+	res, err := tx.ExecContext(ctx, query, int64((7 * 24 * time.Hour).Seconds()), filter)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
 
-// UpdateAnimeViewData memperbarui total dan peningkatan mingguan.
-func (s *DBStore) UpdateAnimeViewData(animeID int, newTotalViews int64, weeklyIncrease int64) error {
-	query := `UPDATE animes SET total_view_count = $1, weekly_view_increase = $2 WHERE anime_id = $3`
-	_, err := s.db.Exec(query, newTotalViews, weeklyIncrease, animeID)
+// GetMatchReviewForPlaylist mengambil review pencocokan anime sebuah playlist.
+// Mengembalikan nil jika playlist belum pernah masuk antrean review.
+func (s *DBStore) GetMatchReviewForPlaylist(ctx context.Context, playlistID string) (*models.AnimeMatchReview, error) {
+	var review models.AnimeMatchReview
+	err := s.db.GetContext(ctx, &review, `SELECT * FROM anime_match_reviews WHERE playlist_id = $1`, playlistID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// CreateMatchReview memasukkan playlist ke antrean review. Playlist yang sudah
+// punya review (apa pun statusnya) tidak diubah.
+func (s *DBStore) CreateMatchReview(ctx context.Context, r models.AnimeMatchReview) error {
+	query := `INSERT INTO anime_match_reviews (playlist_id, channel_id, playlist_title, extracted_title, candidate_anime_id, candidate_title, score, status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (playlist_id) DO NOTHING;`
+	_, err := s.db.ExecContext(ctx, query, r.PlaylistID, r.ChannelID, r.PlaylistTitle, r.ExtractedTitle, r.CandidateAnimeID, r.CandidateTitle, r.Score, models.MatchPending)
 	return err
 }
 
-// GetTopWeeklyAnimes mengambil 10 anime teratas berdasarkan peningkatan mingguan.
-func (s *DBStore) GetTopWeeklyAnimes() ([]models.Anime, error) {
-	var animes []models.Anime
-	query := `SELECT * FROM animes WHERE thumbnail_url IS NOT NULL AND weekly_view_increase > 0 ORDER BY weekly_view_increase DESC NULLS LAST LIMIT 10`
-	err := s.db.Select(&animes, query)
+// GetMatchReviews mengambil review dengan status tertentu, yang terlama lebih dulu.
+func (s *DBStore) GetMatchReviews(ctx context.Context, status string, limit int) ([]models.AnimeMatchReview, error) {
+	reviews := []models.AnimeMatchReview{}
+	query := `SELECT * FROM anime_match_reviews WHERE status = $1 ORDER BY created_at ASC, review_id ASC LIMIT $2`
+	err := s.db.SelectContext(ctx, &reviews, query, status, limit)
+	return reviews, err
+}
+
+// ResolveMatchReview memutuskan review yang masih pending. Untuk MatchAccepted,
+// animeID nil berarti anime kandidat. Mengembalikan ErrNotFound jika review
+// tidak ada, sudah diputuskan, atau anime tujuannya tidak ada.
+func (s *DBStore) ResolveMatchReview(ctx context.Context, reviewID int64, status string, animeID *int) (*models.AnimeMatchReview, error) {
+	var review models.AnimeMatchReview
+	query := `
+		UPDATE anime_match_reviews
+		SET status = $2,
+		    resolved_anime_id = CASE WHEN $2 = 'accepted' THEN COALESCE($3, candidate_anime_id) END,
+		    resolved_at = NOW()
+		WHERE review_id = $1 AND status = 'pending'
+		  AND ($2 <> 'accepted' OR COALESCE($3, candidate_anime_id) IS NOT NULL)
+		  AND ($3::int IS NULL OR EXISTS (SELECT 1 FROM animes WHERE anime_id = $3))
+		RETURNING *`
+	err := s.db.GetContext(ctx, &review, query, reviewID, status, animeID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetAvailableEpisodeIDs mengambil ID video semua episode yang masih tersedia
+// di playlist milik channel-channel tertentu.
+func (s *DBStore) GetAvailableEpisodeIDs(ctx context.Context, channelIDs []string) ([]string, error) {
+	videoIDs := []string{}
+	query := `
+		SELECT e.video_id FROM episodes e
+		JOIN playlists p ON p.playlist_id = e.playlist_id
+		WHERE p.channel_id = ANY($1) AND e.unavailable_at IS NULL
+		ORDER BY e.video_id`
+	err := s.db.SelectContext(ctx, &videoIDs, query, channelIDs)
+	return videoIDs, err
+}
+
+// UpdateEpisodeStatistics memperbarui jumlah views, like dan komentar banyak
+// episode sekaligus. Mengembalikan jumlah episode yang berubah.
+func (s *DBStore) UpdateEpisodeStatistics(ctx context.Context, stats []models.EpisodeStatistics) (int64, error) {
+	if len(stats) == 0 {
+		return 0, nil
+	}
+	videoIDs := make([]string, len(stats))
+	views := make([]int64, len(stats))
+	likes := make([]int64, len(stats))
+	comments := make([]int64, len(stats))
+	for i, st := range stats {
+		videoIDs[i], views[i], likes[i], comments[i] = st.VideoID, st.ViewCount, st.LikeCount, st.CommentCount
+	}
+	query := `
+		UPDATE episodes e SET view_count = s.view_count, like_count = s.like_count, comment_count = s.comment_count
+		FROM unnest($1::text[], $2::bigint[], $3::bigint[], $4::bigint[]) AS s(video_id, view_count, like_count, comment_count)
+		WHERE e.video_id = s.video_id
+		  AND (e.view_count, e.like_count, e.comment_count) IS DISTINCT FROM (s.view_count, s.like_count, s.comment_count)`
+	res, err := s.db.ExecContext(ctx, query, videoIDs, views, likes, comments)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// viewBaseline adalah views anime v pada awal jendela $1 (dalam detik): snapshot
+// terakhir yang diambil sebelum jendela dimulai, atau snapshot paling awal jika
+// anime baru tercatat di dalam jendela. NULL jika anime belum punya snapshot.
+const viewBaseline = `COALESCE(
+	(SELECT view_count FROM anime_view_snapshots WHERE anime_id = v.anime_id AND captured_at <= NOW() - $1 * INTERVAL '1 second' ORDER BY captured_at DESC LIMIT 1),
+	(SELECT view_count FROM anime_view_snapshots WHERE anime_id = v.anime_id ORDER BY captured_at ASC LIMIT 1))`
+
+// SaveViewSnapshots mencatat views setiap episode di stats dan total views
+// setiap anime dalam satu transaksi, dengan waktu yang sama untuk semua baris.
+// Dipanggil setelah RecomputeAnimeAggregates.
+func (s *DBStore) SaveViewSnapshots(ctx context.Context, stats []models.EpisodeStatistics) error {
+	videoIDs := make([]string, len(stats))
+	views := make([]int64, len(stats))
+	for i, st := range stats {
+		videoIDs[i], views[i] = st.VideoID, st.ViewCount
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO episode_view_snapshots (video_id, captured_at, view_count)
+		SELECT s.video_id, NOW(), s.view_count
+		FROM unnest($1::text[], $2::bigint[]) AS s(video_id, view_count)
+		WHERE EXISTS (SELECT 1 FROM episodes WHERE video_id = s.video_id)
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, videoIDs, views); err != nil {
+		return err
+	}
+	query = `
+		INSERT INTO anime_view_snapshots (anime_id, captured_at, view_count)
+		SELECT anime_id, NOW(), total_view_count FROM animes WHERE total_view_count IS NOT NULL
+		ON CONFLICT DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetTrendingAnimes mengambil anime dengan pertambahan views terbesar selama
+// window terakhir, dihitung dari snapshot views. Pertambahannya diisi di ViewIncrease.
+func (s *DBStore) GetTrendingAnimes(ctx context.Context, window time.Duration, limit int) ([]models.Anime, error) {
+	animes := []models.Anime{}
+	query := `
+		SELECT * FROM (
+			SELECT v.*, COALESCE(v.total_view_count, 0) - COALESCE(` + viewBaseline + `, v.total_view_count, 0) AS view_increase
+			FROM animes v
+			WHERE v.thumbnail_url IS NOT NULL
+		) t
+		WHERE view_increase > 0
+		ORDER BY view_increase DESC
+		LIMIT $2`
+	err := s.db.SelectContext(ctx, &animes, query, int64(window.Seconds()), limit)
 	return animes, err
 }
 
+// GetAnimeViewSeries mengambil deret total views sebuah anime sejak since,
+// satu titik per bucket ("hour", "day" atau "week", dalam UTC). Mengembalikan
+// ErrNotFound jika anime tidak ada.
+func (s *DBStore) GetAnimeViewSeries(ctx context.Context, animeID int, bucket string, since time.Time) ([]models.ViewPoint, error) {
+	return s.viewSeries(ctx, "animes", "anime_view_snapshots", "anime_id", animeID, bucket, since)
+}
+
+// GetEpisodeViewSeries mengambil deret views sebuah episode sejak since, satu
+// titik per bucket. Mengembalikan ErrNotFound jika episode tidak ada.
+func (s *DBStore) GetEpisodeViewSeries(ctx context.Context, videoID string, bucket string, since time.Time) ([]models.ViewPoint, error) {
+	return s.viewSeries(ctx, "episodes", "episode_view_snapshots", "video_id", videoID, bucket, since)
+}
+
+// viewSeries mengambil snapshot terakhir setiap bucket dari tabel snapshot,
+// lalu mengisi ViewIncrease dari titik sebelumnya. Titik pertama dibandingkan
+// dengan snapshot terakhir sebelum since.
+func (s *DBStore) viewSeries(ctx context.Context, table, snapshots, key string, id interface{}, bucket string, since time.Time) ([]models.ViewPoint, error) {
+	var exists bool
+	if err := s.db.GetContext(ctx, &exists, fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1)`, table, key), id); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	points := []models.ViewPoint{}
+	query := fmt.Sprintf(`
+		SELECT date_trunc($2, captured_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket,
+		       (array_agg(view_count ORDER BY captured_at DESC))[1] AS view_count
+		FROM %s
+		WHERE %s = $1 AND captured_at >= $3
+		GROUP BY 1
+		ORDER BY 1`, snapshots, key)
+	if err := s.db.SelectContext(ctx, &points, query, id, bucket, since); err != nil {
+		return nil, err
+	}
+
+	var prev sql.NullInt64
+	query = fmt.Sprintf(`SELECT view_count FROM %s WHERE %s = $1 AND captured_at < $2 ORDER BY captured_at DESC LIMIT 1`, snapshots, key)
+	if err := s.db.GetContext(ctx, &prev, query, id, since); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	for i := range points {
+		if prev.Valid {
+			increase := points[i].ViewCount - prev.Int64
+			points[i].ViewIncrease = &increase
+		}
+		prev = sql.NullInt64{Int64: points[i].ViewCount, Valid: true}
+	}
+	return points, nil
+}
+
+// DownsampleViewSnapshots menjaga tabel snapshot tetap kecil: snapshot yang
+// lebih tua dari keepHourly disisakan satu per hari, dan yang lebih tua dari
+// keepDaily disisakan satu per minggu (yang terakhir di setiap bucket).
+// Mengembalikan jumlah snapshot yang dihapus.
+func (s *DBStore) DownsampleViewSnapshots(ctx context.Context, keepHourly, keepDaily time.Duration) (int64, error) {
+	tables := []struct{ name, key string }{
+		{"episode_view_snapshots", "video_id"},
+		{"anime_view_snapshots", "anime_id"},
+	}
+	steps := []struct {
+		bucket string
+		after  time.Duration
+	}{
+		{"day", keepHourly},
+		{"week", keepDaily},
+	}
+	var deleted int64
+	for _, t := range tables {
+		for _, step := range steps {
+			query := fmt.Sprintf(`
+				DELETE FROM %[1]s s
+				USING (
+					SELECT %[2]s, captured_at,
+					       ROW_NUMBER() OVER (PARTITION BY %[2]s, date_trunc($1, captured_at AT TIME ZONE 'UTC') ORDER BY captured_at DESC) AS rn
+					FROM %[1]s
+					WHERE captured_at < NOW() - $2 * INTERVAL '1 second'
+				) d
+				WHERE s.%[2]s = d.%[2]s AND s.captured_at = d.captured_at AND d.rn > 1`, t.name, t.key)
+			res, err := s.db.ExecContext(ctx, query, step.bucket, int64(step.after.Seconds()))
+			if err != nil {
+				return deleted, err
+			}
+			n, err := res.RowsAffected()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+	}
+	return deleted, nil
+}
+
 // GetAllChannelsMap mengambil semua data channel dan mengembalikannya sebagai map.
-func (s *DBStore) GetAllChannelsMap() (map[string]models.Channel, error) {
+func (s *DBStore) GetAllChannelsMap(ctx context.Context) (map[string]models.Channel, error) {
 	channels := []models.Channel{}
 	query := `SELECT * FROM channels`
-	err := s.db.Select(&channels, query)
+	err := s.db.SelectContext(ctx, &channels, query)
 	if err != nil {
 		return nil, err
 	}
@@ -216,32 +907,367 @@ func (s *DBStore) GetAllChannelsMap() (map[string]models.Channel, error) {
 	return channelMap, nil
 }
 
+// ListChannels mengambil semua channel beserta konfigurasinya, diurutkan sesuai urutan proses worker.
+func (s *DBStore) ListChannels(ctx context.Context) ([]models.Channel, error) {
+	channels := []models.Channel{}
+	query := `SELECT * FROM channels ORDER BY enabled DESC, priority DESC, name ASC`
+	err := s.db.SelectContext(ctx, &channels, query)
+	return channels, err
+}
+
+// GetEnabledChannels mengambil channel yang harus disinkronkan worker, priority tertinggi lebih dulu.
+func (s *DBStore) GetEnabledChannels(ctx context.Context) ([]models.Channel, error) {
+	channels := []models.Channel{}
+	query := `SELECT * FROM channels WHERE enabled ORDER BY priority DESC, name ASC`
+	err := s.db.SelectContext(ctx, &channels, query)
+	return channels, err
+}
+
+// AddChannel mendaftarkan channel baru sebagai target worker, atau memperbarui
+// konfigurasinya jika channel sudah ada. Foto profil tidak disentuh.
+func (s *DBStore) AddChannel(ctx context.Context, channel models.Channel) error {
+	query := `INSERT INTO channels (channel_id, name, url, enabled, default_language, priority, playlist_include, playlist_exclude) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (channel_id) DO UPDATE SET name = EXCLUDED.name, url = EXCLUDED.url, enabled = EXCLUDED.enabled, default_language = EXCLUDED.default_language, priority = EXCLUDED.priority, playlist_include = EXCLUDED.playlist_include, playlist_exclude = EXCLUDED.playlist_exclude;`
+	_, err := s.db.ExecContext(ctx, query, channel.ID, channel.Name, channel.URL, channel.Enabled, channel.DefaultLanguage, channel.Priority, channel.PlaylistInclude, channel.PlaylistExclude)
+	return err
+}
+
+// SetChannelEnabled mengaktifkan atau menonaktifkan channel. Mengembalikan
+// ErrNotFound jika channel belum terdaftar.
+func (s *DBStore) SetChannelEnabled(ctx context.Context, channelID string, enabled bool) error {
+	query := `UPDATE channels SET enabled = $2 WHERE channel_id = $1`
+	res, err := s.db.ExecContext(ctx, query, channelID, enabled)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("channel %s: %w", channelID, ErrNotFound)
+	}
+	return nil
+}
+
 // GetAllAnimes mengambil semua anime dari database (versi sederhana).
-func (s *DBStore) GetAllAnimes() ([]models.Anime, error) {
+func (s *DBStore) GetAllAnimes(ctx context.Context) ([]models.Anime, error) {
 	var animes []models.Anime
 	query := `SELECT * FROM animes WHERE thumbnail_url IS NOT NULL ORDER BY title ASC`
-	err := s.db.Select(&animes, query)
+	err := s.db.SelectContext(ctx, &animes, query)
 	return animes, err
 }
 
-// GetAnimeWithEpisodes mengambil satu anime beserta semua episodenya.
-func (s *DBStore) GetAnimeWithEpisodes(animeID int) (*models.AnimeWithEpisodes, error) {
+// GetAnimeWithEpisodes mengambil satu anime beserta episodenya. Episode yang
+// tidak tersedia hanya ikut jika includeUnavailable bernilai true.
+func (s *DBStore) GetAnimeWithEpisodes(ctx context.Context, animeID int, includeUnavailable bool) (*models.AnimeWithEpisodes, error) {
 	var anime models.Anime
 	queryAnime := `SELECT a.*, (array_agg(p.channel_id))[1] as channel_id, string_agg(DISTINCT p.language, ',') as languages FROM animes a JOIN playlists p ON a.anime_id = p.anime_id WHERE a.anime_id = $1 GROUP BY a.anime_id`
-	err := s.db.Get(&anime, queryAnime, animeID)
+	err := s.db.GetContext(ctx, &anime, queryAnime, animeID)
 	if err != nil {
 		return nil, err
 	}
 
-	var episodes []models.Episode
-	queryEpisodes := `SELECT e.* FROM episodes e JOIN playlists p ON e.playlist_id = p.playlist_id WHERE p.anime_id = $1 ORDER BY e.episode_number ASC, e.published_at ASC;`
-	err = s.db.Select(&episodes, queryEpisodes, animeID)
+	var seasons []models.Season
+	err = s.db.SelectContext(ctx, &seasons, `SELECT * FROM seasons WHERE anime_id = $1`, animeID)
+	if err != nil {
+		return nil, err
+	}
+	seasonByID := make(map[int]models.Season, len(seasons))
+	for _, season := range seasons {
+		seasonByID[season.ID] = season
+	}
+
+	// Episode diurutkan per season lalu per playlist agar "Episode 01" Season 1
+	// dan Season 2, atau Sub Indo dan English Sub, tidak tercampur.
+	var rows []struct {
+		models.Episode
+		SeasonID  *int   `db:"season_id"`
+		ChannelID string `db:"playlist_channel_id"`
+		Language  string `db:"playlist_language"`
+	}
+	queryEpisodes := `SELECT e.*, p.season_id, p.channel_id AS playlist_channel_id, p.language AS playlist_language FROM episodes e JOIN playlists p ON e.playlist_id = p.playlist_id LEFT JOIN seasons s ON s.season_id = p.season_id WHERE p.anime_id = $1 AND ($2 OR e.unavailable_at IS NULL) ORDER BY s.number ASC NULLS LAST, s.part ASC, p.language ASC, p.playlist_id ASC, e.episode_number ASC, e.published_at ASC;`
+	err = s.db.SelectContext(ctx, &rows, queryEpisodes, animeID, includeUnavailable)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AnimeWithEpisodes{Anime: anime, Seasons: []models.SeasonWithEpisodes{}}
+	for _, row := range rows {
+		var season models.Season
+		if row.SeasonID != nil {
+			season = seasonByID[*row.SeasonID]
+		}
+		if n := len(result.Seasons); n == 0 || result.Seasons[n-1].ID != season.ID || result.Seasons[n-1].PlaylistID != row.PlaylistID {
+			result.Seasons = append(result.Seasons, models.SeasonWithEpisodes{Season: season, PlaylistID: row.PlaylistID, ChannelID: row.ChannelID, Language: row.Language})
+		}
+		last := &result.Seasons[len(result.Seasons)-1]
+		last.Episodes = append(last.Episodes, row.Episode)
+	}
+	return result, nil
+}
+
+// GetPlaylistSyncState mengambil status sinkronisasi playlist, atau nil jika
+// playlist belum pernah disinkronkan.
+func (s *DBStore) GetPlaylistSyncState(ctx context.Context, playlistID string) (*models.PlaylistSyncState, error) {
+	var state models.PlaylistSyncState
+	query := `SELECT * FROM playlist_sync_state WHERE playlist_id = $1`
+	err := s.db.GetContext(ctx, &state, query, playlistID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SavePlaylistSyncState menyimpan status sinkronisasi playlist.
+func (s *DBStore) SavePlaylistSyncState(ctx context.Context, state models.PlaylistSyncState) error {
+	query := `INSERT INTO playlist_sync_state (playlist_id, last_video_id, last_published_at, last_page_token, item_count, last_full_sync_at, last_synced_at) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (playlist_id) DO UPDATE SET last_video_id = EXCLUDED.last_video_id, last_published_at = EXCLUDED.last_published_at, last_page_token = EXCLUDED.last_page_token, item_count = EXCLUDED.item_count, last_full_sync_at = EXCLUDED.last_full_sync_at, last_synced_at = EXCLUDED.last_synced_at;`
+	_, err := s.db.ExecContext(ctx, query, state.PlaylistID, state.LastVideoID, state.LastPublishedAt, state.LastPageToken, state.ItemCount, state.LastFullSyncAt, state.LastSyncedAt)
+	return err
+}
+
+// CreateSyncRun mencatat run worker baru dengan status running dan mengembalikan ID-nya.
+func (s *DBStore) CreateSyncRun(ctx context.Context, task string, startedAt time.Time) (int64, error) {
+	var runID int64
+	query := `INSERT INTO sync_runs (task, started_at, status) VALUES ($1, $2, $3) RETURNING run_id`
+	err := s.db.QueryRowxContext(ctx, query, task, startedAt, models.SyncRunning).Scan(&runID)
+	return runID, err
+}
+
+// FinishSyncRun menyimpan hasil akhir sebuah run.
+func (s *DBStore) FinishSyncRun(ctx context.Context, run models.SyncRun) error {
+	query := `UPDATE sync_runs SET finished_at = $2, status = $3, playlists_seen = $4, episodes_inserted = $5, episodes_updated = $6, episodes_removed = $7, api_units = $8, error_count = $9, error = $10 WHERE run_id = $1`
+	_, err := s.db.ExecContext(ctx, query, run.ID, run.FinishedAt, run.Status, run.PlaylistsSeen, run.EpisodesInserted, run.EpisodesUpdated, run.EpisodesRemoved, run.APIUnits, run.ErrorCount, run.Error)
+	return err
+}
+
+// SaveSyncRunChannel menyimpan ringkasan satu channel dalam sebuah run.
+func (s *DBStore) SaveSyncRunChannel(ctx context.Context, ch models.SyncRunChannel) error {
+	query := `INSERT INTO sync_run_channels (run_id, channel_id, started_at, finished_at, playlists_seen, episodes_inserted, episodes_updated, episodes_removed, api_units, error_count, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (run_id, channel_id) DO NOTHING;`
+	_, err := s.db.ExecContext(ctx, query, ch.RunID, ch.ChannelID, ch.StartedAt, ch.FinishedAt, ch.PlaylistsSeen, ch.EpisodesInserted, ch.EpisodesUpdated, ch.EpisodesRemoved, ch.APIUnits, ch.ErrorCount, ch.Error)
+	return err
+}
+
+// SaveSyncRunPlaylist menyimpan hasil satu playlist dalam sebuah run.
+func (s *DBStore) SaveSyncRunPlaylist(ctx context.Context, p models.SyncRunPlaylist) error {
+	query := `INSERT INTO sync_run_playlists (run_id, playlist_id, channel_id, started_at, finished_at, status, full_sync, episodes_inserted, episodes_updated, episodes_removed, api_units, error) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) ON CONFLICT (run_id, playlist_id) DO NOTHING;`
+	_, err := s.db.ExecContext(ctx, query, p.RunID, p.PlaylistID, p.ChannelID, p.StartedAt, p.FinishedAt, p.Status, p.FullSync, p.EpisodesInserted, p.EpisodesUpdated, p.EpisodesRemoved, p.APIUnits, p.Error)
+	return err
+}
+
+// GetSyncRuns mengambil run terbaru beserta ringkasan per channel-nya.
+func (s *DBStore) GetSyncRuns(ctx context.Context, limit int) ([]models.SyncRun, error) {
+	runs := []models.SyncRun{}
+	query := `SELECT * FROM sync_runs ORDER BY started_at DESC LIMIT $1`
+	if err := s.db.SelectContext(ctx, &runs, query, limit); err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return runs, nil
+	}
+
+	runIDs := make([]int64, len(runs))
+	for i, run := range runs {
+		runIDs[i] = run.ID
+	}
+	var channels []models.SyncRunChannel
+	queryChannels := `SELECT * FROM sync_run_channels WHERE run_id = ANY($1) ORDER BY started_at ASC`
+	if err := s.db.SelectContext(ctx, &channels, queryChannels, runIDs); err != nil {
+		return nil, err
+	}
+	byRun := make(map[int64][]models.SyncRunChannel)
+	for _, ch := range channels {
+		byRun[ch.RunID] = append(byRun[ch.RunID], ch)
+	}
+	for i := range runs {
+		runs[i].Channels = byRun[runs[i].ID]
+	}
+	return runs, nil
+}
+
+// GetSyncRun mengambil satu run beserta rincian per channel dan per playlist.
+// Mengembalikan nil jika run tidak ditemukan.
+func (s *DBStore) GetSyncRun(ctx context.Context, runID int64) (*models.SyncRun, error) {
+	var run models.SyncRun
+	err := s.db.GetContext(ctx, &run, `SELECT * FROM sync_runs WHERE run_id = $1`, runID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return &models.AnimeWithEpisodes{
-		Anime:    anime,
-		Episodes: episodes,
-	}, nil
+	if err := s.db.SelectContext(ctx, &run.Channels, `SELECT * FROM sync_run_channels WHERE run_id = $1 ORDER BY started_at ASC`, runID); err != nil {
+		return nil, err
+	}
+	var playlists []models.SyncRunPlaylist
+	if err := s.db.SelectContext(ctx, &playlists, `SELECT * FROM sync_run_playlists WHERE run_id = $1 ORDER BY started_at ASC`, runID); err != nil {
+		return nil, err
+	}
+	for i := range run.Channels {
+		for _, p := range playlists {
+			if p.ChannelID == run.Channels[i].ChannelID {
+				run.Channels[i].Playlists = append(run.Channels[i].Playlists, p)
+			}
+		}
+	}
+	return &run, nil
+}
+
+// AcquireLease mengambil lease name untuk holder selama ttl jika lease kosong,
+// sudah kedaluwarsa, atau memang milik holder. Waktu dihitung oleh PostgreSQL
+// agar jam antar instance yang berbeda tidak berpengaruh. Jika lease dipegang
+// instance lain, lease milik instance tersebut dikembalikan dengan acquired false.
+func (s *DBStore) AcquireLease(ctx context.Context, name string, holder string, ttl time.Duration) (models.Lease, bool, error) {
+	var lease models.Lease
+	query := `INSERT INTO worker_leases (name, holder, acquired_at, heartbeat_at, expires_at) VALUES ($1, $2, NOW(), NOW(), NOW() + $3 * INTERVAL '1 millisecond') ON CONFLICT (name) DO UPDATE SET holder = EXCLUDED.holder, acquired_at = EXCLUDED.acquired_at, heartbeat_at = EXCLUDED.heartbeat_at, expires_at = EXCLUDED.expires_at WHERE worker_leases.expires_at < NOW() OR worker_leases.holder = EXCLUDED.holder RETURNING *;`
+	err := s.db.GetContext(ctx, &lease, query, name, holder, ttl.Milliseconds())
+	if err == nil {
+		return lease, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return models.Lease{}, false, err
+	}
+	err = s.db.GetContext(ctx, &lease, `SELECT * FROM worker_leases WHERE name = $1`, name)
+	return lease, false, err
+}
+
+// RenewLease memperpanjang lease milik holder selama ttl dari sekarang.
+// Mengembalikan ErrLeaseLost jika lease sudah bukan milik holder.
+func (s *DBStore) RenewLease(ctx context.Context, name string, holder string, ttl time.Duration) error {
+	query := `UPDATE worker_leases SET heartbeat_at = NOW(), expires_at = NOW() + $3 * INTERVAL '1 millisecond' WHERE name = $1 AND holder = $2`
+	res, err := s.db.ExecContext(ctx, query, name, holder, ttl.Milliseconds())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// ReleaseLease melepas lease milik holder agar instance lain bisa langsung mengambilnya.
+func (s *DBStore) ReleaseLease(ctx context.Context, name string, holder string) error {
+	query := `DELETE FROM worker_leases WHERE name = $1 AND holder = $2`
+	_, err := s.db.ExecContext(ctx, query, name, holder)
+	return err
+}
+
+// ReserveQuota menambahkan pemakaian unit quota untuk satu endpoint dan API
+// key pada hari tertentu, hanya jika total pemakaian hari itu tidak melewati
+// budget. Total harian di api_quota_days dicek dan ditambah dalam satu upsert
+// bersyarat yang mengunci baris hari itu, sehingga worker di proses lain tidak
+// bisa melewati budget bersamaan; rincian per endpoint dan key hanya dicatat
+// jika total berhasil ditambah. Mengembalikan total pemakaian hari itu setelah
+// (atau, jika ditolak, tanpa) units ini.
+func (s *DBStore) ReserveQuota(ctx context.Context, day string, endpoint string, keyLabel string, units int, budget int64) (int64, bool, error) {
+	query := `
+		WITH total AS (
+			INSERT INTO api_quota_days (usage_date, units)
+			SELECT $1::date, $4::bigint WHERE $4::bigint <= $5::bigint
+			ON CONFLICT (usage_date) DO UPDATE SET units = api_quota_days.units + EXCLUDED.units
+			WHERE api_quota_days.units + EXCLUDED.units <= $5::bigint
+			RETURNING units
+		), detail AS (
+			INSERT INTO api_quota_usage (usage_date, endpoint, key_label, units, calls)
+			SELECT $1::date, $2, $3, $4::bigint, 1 FROM total
+			ON CONFLICT (usage_date, endpoint, key_label) DO UPDATE SET units = api_quota_usage.units + EXCLUDED.units, calls = api_quota_usage.calls + 1
+		)
+		SELECT units FROM total`
+	var used int64
+	err := s.db.GetContext(ctx, &used, query, day, endpoint, keyLabel, units, budget)
+	if err == nil {
+		return used, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+	// Ditolak; total saat ini hanya dibaca untuk pesan error.
+	err = s.db.GetContext(ctx, &used, `SELECT COALESCE((SELECT units FROM api_quota_days WHERE usage_date = $1::date), 0)`, day)
+	return used, false, err
+}
+
+// GetQuotaUsage mengambil pemakaian unit quota per endpoint pada hari tertentu.
+func (s *DBStore) GetQuotaUsage(ctx context.Context, day string) (map[string]int64, error) {
+	var rows []struct {
+		Endpoint string `db:"endpoint"`
+		Units    int64  `db:"units"`
+	}
+	query := `SELECT endpoint, SUM(units) AS units FROM api_quota_usage WHERE usage_date = $1::date GROUP BY endpoint`
+	if err := s.db.SelectContext(ctx, &rows, query, day); err != nil {
+		return nil, err
+	}
+	usage := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		usage[r.Endpoint] = r.Units
+	}
+	return usage, nil
+}
+
+// GetQuotaUsageByKey mengambil pemakaian unit quota per label API key pada hari tertentu.
+func (s *DBStore) GetQuotaUsageByKey(ctx context.Context, day string) (map[string]int64, error) {
+	var rows []struct {
+		KeyLabel string `db:"key_label"`
+		Units    int64  `db:"units"`
+	}
+	query := `SELECT key_label, SUM(units) AS units FROM api_quota_usage WHERE usage_date = $1::date GROUP BY key_label`
+	if err := s.db.SelectContext(ctx, &rows, query, day); err != nil {
+		return nil, err
+	}
+	usage := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		usage[r.KeyLabel] = r.Units
+	}
+	return usage, nil
+}
+
+// GetCachedResponse mengambil ETag dan body respons YouTube yang tersimpan untuk sebuah resource.
+// Mengembalikan etag kosong jika belum ada.
+func (s *DBStore) GetCachedResponse(ctx context.Context, resourceKey string) (string, []byte, error) {
+	var row struct {
+		ETag string `db:"etag"`
+		Body string `db:"body"`
+	}
+	query := `SELECT etag, body FROM api_etags WHERE resource_key = $1`
+	err := s.db.GetContext(ctx, &row, query, resourceKey)
+	if err == sql.ErrNoRows {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return row.ETag, []byte(row.Body), nil
+}
+
+// SaveCachedResponse menyimpan ETag dan body respons terbaru untuk sebuah resource.
+func (s *DBStore) SaveCachedResponse(ctx context.Context, resourceKey string, etag string, body []byte) error {
+	query := `INSERT INTO api_etags (resource_key, etag, body, updated_at) VALUES ($1, $2, $3, NOW()) ON CONFLICT (resource_key) DO UPDATE SET etag = EXCLUDED.etag, body = EXCLUDED.body, updated_at = EXCLUDED.updated_at;`
+	_, err := s.db.ExecContext(ctx, query, resourceKey, etag, string(body))
+	return err
+}
+
+// DeleteCachedResponse menghapus ETag sebuah resource sehingga request berikutnya tidak kondisional.
+func (s *DBStore) DeleteCachedResponse(ctx context.Context, resourceKey string) error {
+	query := `DELETE FROM api_etags WHERE resource_key = $1`
+	_, err := s.db.ExecContext(ctx, query, resourceKey)
+	return err
+}
+
+// PruneCachedResponses menghapus respons tersimpan yang tidak diperbarui
+// selama olderThan, misalnya batch videos yang ID-nya sudah bergeser.
+// Resource yang masih dipakai hanya kehilangan ETag-nya, sehingga request
+// berikutnya diambil penuh lalu disimpan lagi. Mengembalikan jumlah baris yang dihapus.
+func (s *DBStore) PruneCachedResponses(ctx context.Context, olderThan time.Duration) (int64, error) {
+	query := `DELETE FROM api_etags WHERE updated_at < NOW() - make_interval(secs => $1)`
+	res, err := s.db.ExecContext(ctx, query, olderThan.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
 }
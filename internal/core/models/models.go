@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Channel merepresentasikan tabel 'channels'
 type Channel struct {
@@ -8,6 +11,35 @@ type Channel struct {
 	Name              string  `db:"name" json:"name"`
 	URL               string  `db:"url" json:"url"`
 	ProfilePictureURL *string `db:"profile_picture_url" json:"profile_picture_url"`
+
+	Enabled         bool    `db:"enabled" json:"enabled"`
+	DefaultLanguage *string `db:"default_language" json:"default_language"`
+	Priority        int     `db:"priority" json:"priority"`
+	PlaylistInclude *string `db:"playlist_include" json:"playlist_include"` // Kata kunci dipisah koma
+	PlaylistExclude *string `db:"playlist_exclude" json:"playlist_exclude"` // Kata kunci dipisah koma
+}
+
+// IncludeKeywords mengembalikan kata kunci playlist_include dalam huruf kecil.
+func (c Channel) IncludeKeywords() []string {
+	return splitKeywords(c.PlaylistInclude)
+}
+
+// ExcludeKeywords mengembalikan kata kunci playlist_exclude dalam huruf kecil.
+func (c Channel) ExcludeKeywords() []string {
+	return splitKeywords(c.PlaylistExclude)
+}
+
+func splitKeywords(list *string) []string {
+	if list == nil {
+		return nil
+	}
+	var keywords []string
+	for _, k := range strings.Split(*list, ",") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keywords = append(keywords, k)
+		}
+	}
+	return keywords
 }
 
 // Anime merepresentasikan tabel 'animes'
@@ -20,8 +52,10 @@ type Anime struct {
 	LastUpdated        *time.Time `db:"last_updated" json:"last_updated"`
 	TotalViewCount     int64      `db:"total_view_count" json:"total_view_count"`
 	WeeklyViewIncrease int64      `db:"weekly_view_increase" json:"weekly_view_increase"`
-	ChannelID          string     `db:"channel_id" json:"channel_id"`
-	Languages          string     `db:"languages" json:"languages"`
+	// ViewIncrease adalah pertambahan views selama jendela yang diminta, hanya diisi untuk daftar trending.
+	ViewIncrease *int64 `db:"view_increase" json:"view_increase,omitempty"`
+	ChannelID    string `db:"channel_id" json:"channel_id"`
+	Languages    string `db:"languages" json:"languages"`
 }
 
 // Playlist merepresentasikan tabel 'playlists'
@@ -29,11 +63,59 @@ type Playlist struct {
 	ID          string  `db:"playlist_id"`
 	ChannelID   string  `db:"channel_id"`
 	AnimeID     *int    `db:"anime_id"`
+	SeasonID    *int    `db:"season_id"`
 	Title       string  `db:"title"`
 	Description *string `db:"description"`
 	Language    string  `db:"language"`
+	// AnimeLocked berarti AnimeID ditentukan admin (merge atau split) dan tidak diubah worker.
+	AnimeLocked bool `db:"anime_locked"`
+}
+
+// Season merepresentasikan tabel 'seasons'. Setiap playlist menunjuk ke satu
+// season anime-nya, sesuai season dan cour/part yang disebut judul playlist.
+type Season struct {
+	ID      int    `db:"season_id" json:"season_id"`
+	AnimeID int    `db:"anime_id" json:"anime_id"`
+	Number  int    `db:"number" json:"number"`
+	Part    int    `db:"part" json:"part"` // 0 berarti seluruh season
+	Label   string `db:"label" json:"label"`
+}
+
+// SeasonWithEpisodes adalah episode satu season dari satu playlist untuk
+// halaman detail. Season yang diunggah beberapa playlist (misalnya Sub Indo dan
+// English Sub) muncul sekali per playlist agar nomor episodenya tidak tercampur.
+// Episode dari playlist yang belum punya season dikelompokkan dengan season_id 0.
+type SeasonWithEpisodes struct {
+	Season
+	PlaylistID string    `json:"playlist_id"`
+	ChannelID  string    `json:"channel_id"`
+	Language   string    `json:"language"`
+	Episodes   []Episode `json:"episodes"`
+}
+
+// ViewPoint adalah satu titik deret waktu views: views terakhir yang tercatat
+// dalam satu bucket (jam, hari atau minggu).
+type ViewPoint struct {
+	Time      time.Time `db:"bucket" json:"time"`
+	ViewCount int64     `db:"view_count" json:"view_count"`
+	// ViewIncrease adalah selisih dengan titik sebelumnya (atau snapshot terakhir
+	// sebelum rentang); nil jika tidak ada pembanding.
+	ViewIncrease *int64 `db:"-" json:"view_increase"`
 }
 
+// ViewSeries adalah deret views sebuah anime atau episode untuk grafik.
+type ViewSeries struct {
+	Bucket string      `json:"bucket"`
+	From   time.Time   `json:"from"`
+	Points []ViewPoint `json:"points"`
+}
+
+// Alasan sebuah episode ditandai tidak tersedia.
+const (
+	EpisodeRemoved = "removed" // Video dihapus atau dikeluarkan dari playlist
+	EpisodePrivate = "private" // Video diprivat oleh pemilik channel
+)
+
 // Episode merepresentasikan tabel 'episodes'
 type Episode struct {
 	VideoID       string     `db:"video_id"`
@@ -43,10 +125,208 @@ type Episode struct {
 	PublishedAt   *time.Time `db:"published_at"`
 	ThumbnailURL  *string    `db:"thumbnail_url"`
 	ViewCount     int64      `db:"view_count"` // Field baru
+	LikeCount     int64      `db:"like_count"`
+	CommentCount  int64      `db:"comment_count"`
+
+	DurationSeconds *int    `db:"duration_seconds"`
+	PrivacyStatus   *string `db:"privacy_status"`
+	Embeddable      *bool   `db:"embeddable"`
+	RegionAllowed   *string `db:"region_allowed"` // Kode negara dipisah koma
+	RegionBlocked   *string `db:"region_blocked"` // Kode negara dipisah koma
+
+	// UnavailableAt terisi sejak video hilang dari playlist atau diprivat.
+	UnavailableAt     *time.Time `db:"unavailable_at"`
+	UnavailableReason *string    `db:"unavailable_reason"`
+
+	// PlayableInCountry diisi oleh API saat klien mengirim parameter country.
+	PlayableInCountry *bool `db:"-"`
+}
+
+// EpisodeStatistics adalah statistik terbaru satu video dari videos.list.
+type EpisodeStatistics struct {
+	VideoID      string
+	ViewCount    int64
+	LikeCount    int64
+	CommentCount int64
+}
+
+// PlayableIn melaporkan apakah episode bisa diputar lewat embed dari negara
+// dengan kode ISO 3166-1 alpha-2 tertentu. Data yang belum diketahui dianggap boleh.
+func (e Episode) PlayableIn(country string) bool {
+	if e.Embeddable != nil && !*e.Embeddable {
+		return false
+	}
+	if e.PrivacyStatus != nil && *e.PrivacyStatus == "private" {
+		return false
+	}
+	country = strings.ToUpper(country)
+	if e.RegionAllowed != nil && *e.RegionAllowed != "" {
+		return containsCode(*e.RegionAllowed, country)
+	}
+	if e.RegionBlocked != nil && *e.RegionBlocked != "" {
+		return !containsCode(*e.RegionBlocked, country)
+	}
+	return true
+}
+
+func containsCode(list, code string) bool {
+	for _, c := range strings.Split(list, ",") {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// PlaylistSyncState merepresentasikan tabel 'playlist_sync_state'
+type PlaylistSyncState struct {
+	PlaylistID      string     `db:"playlist_id"`
+	LastVideoID     *string    `db:"last_video_id"`
+	LastPublishedAt *time.Time `db:"last_published_at"`
+	LastPageToken   string     `db:"last_page_token"`
+	ItemCount       int        `db:"item_count"`
+	LastFullSyncAt  *time.Time `db:"last_full_sync_at"`
+	LastSyncedAt    time.Time  `db:"last_synced_at"`
+}
+
+// Status sebuah sync run dan playlist di dalamnya.
+const (
+	SyncRunning   = "running"
+	SyncSucceeded = "succeeded"
+	SyncPartial   = "partial" // Sebagian playlist gagal
+	SyncFailed    = "failed"  // Run berhenti lebih awal
+	SyncSynced    = "synced"
+	SyncUnchanged = "unchanged"
+	// SyncPendingReview berarti playlist menunggu keputusan review pencocokan anime.
+	SyncPendingReview = "pending_review"
+)
+
+// Task terjadwal worker yang dicatat di riwayat sync run.
+const (
+	TaskDiscovery  = "discovery"  // Playlist dan episode baru, secara inkremental
+	TaskReconcile  = "reconcile"  // Sinkronisasi penuh semua playlist
+	TaskViews      = "views"      // Statistik episode dan total views anime
+	TaskAvatars    = "avatars"    // Nama dan foto profil channel
+	TaskDownsample = "downsample" // Merapikan snapshot views lama
+)
+
+// SyncCounts adalah penghitung yang dicatat untuk run, channel dan playlist.
+type SyncCounts struct {
+	EpisodesInserted int   `db:"episodes_inserted" json:"episodes_inserted"`
+	EpisodesUpdated  int   `db:"episodes_updated" json:"episodes_updated"`
+	EpisodesRemoved  int   `db:"episodes_removed" json:"episodes_removed"`
+	APIUnits         int64 `db:"api_units" json:"api_units"`
+}
+
+// Add menambahkan penghitung o ke c.
+func (c *SyncCounts) Add(o SyncCounts) {
+	c.EpisodesInserted += o.EpisodesInserted
+	c.EpisodesUpdated += o.EpisodesUpdated
+	c.EpisodesRemoved += o.EpisodesRemoved
+	c.APIUnits += o.APIUnits
+}
+
+// SyncRun merepresentasikan tabel 'sync_runs'
+type SyncRun struct {
+	ID            int64      `db:"run_id" json:"run_id"`
+	Task          string     `db:"task" json:"task"`
+	StartedAt     time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt    *time.Time `db:"finished_at" json:"finished_at"`
+	Status        string     `db:"status" json:"status"`
+	PlaylistsSeen int        `db:"playlists_seen" json:"playlists_seen"`
+	SyncCounts
+	ErrorCount int     `db:"error_count" json:"error_count"`
+	Error      *string `db:"error" json:"error"` // Penyebab run berhenti lebih awal
+
+	Channels []SyncRunChannel `db:"-" json:"channels,omitempty"`
+}
+
+// SyncRunChannel merepresentasikan tabel 'sync_run_channels'
+type SyncRunChannel struct {
+	RunID         int64     `db:"run_id" json:"-"`
+	ChannelID     string    `db:"channel_id" json:"channel_id"`
+	StartedAt     time.Time `db:"started_at" json:"started_at"`
+	FinishedAt    time.Time `db:"finished_at" json:"finished_at"`
+	PlaylistsSeen int       `db:"playlists_seen" json:"playlists_seen"`
+	SyncCounts
+	ErrorCount int     `db:"error_count" json:"error_count"`
+	Error      *string `db:"error" json:"error"`
+
+	Playlists []SyncRunPlaylist `db:"-" json:"playlists,omitempty"`
 }
 
-// AnimeWithEpisodes adalah struct gabungan untuk halaman detail.
+// SyncRunPlaylist merepresentasikan tabel 'sync_run_playlists'
+type SyncRunPlaylist struct {
+	RunID      int64     `db:"run_id" json:"-"`
+	PlaylistID string    `db:"playlist_id" json:"playlist_id"`
+	ChannelID  string    `db:"channel_id" json:"-"`
+	StartedAt  time.Time `db:"started_at" json:"started_at"`
+	FinishedAt time.Time `db:"finished_at" json:"finished_at"`
+	Status     string    `db:"status" json:"status"`
+	FullSync   bool      `db:"full_sync" json:"full_sync"`
+	SyncCounts
+	Error *string `db:"error" json:"error"`
+}
+
+// Lease merepresentasikan tabel 'worker_leases'
+type Lease struct {
+	Name        string    `db:"name"`
+	Holder      string    `db:"holder"`
+	AcquiredAt  time.Time `db:"acquired_at"`
+	HeartbeatAt time.Time `db:"heartbeat_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
+// AnimeWithEpisodes adalah struct gabungan untuk halaman detail. Episode
+// dikelompokkan per season dan playlist, berurutan sesuai nomor season dan part.
 type AnimeWithEpisodes struct {
 	Anime
-	Episodes []Episode
+	Seasons []SeasonWithEpisodes
+}
+
+// AnimeSuggestion adalah satu saran anime untuk autocomplete kotak pencarian.
+type AnimeSuggestion struct {
+	ID           int     `db:"anime_id" json:"anime_id"`
+	Title        string  `db:"title" json:"title"`
+	ThumbnailURL *string `db:"thumbnail_url" json:"thumbnail_url"`
+}
+
+// AnimeTitle adalah judul atau alias satu anime untuk pencocokan judul playlist.
+type AnimeTitle struct {
+	ID    int    `db:"anime_id"`
+	Title string `db:"title"`
+}
+
+// AnimeAlias merepresentasikan tabel 'anime_aliases': judul lain sebuah anime,
+// misalnya judul bahasa Inggris atau judul anime lain yang sudah digabung ke anime ini.
+type AnimeAlias struct {
+	ID        int64     `db:"alias_id" json:"alias_id"`
+	AnimeID   int       `db:"anime_id" json:"anime_id"`
+	Alias     string    `db:"alias" json:"alias"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Status review pencocokan anime.
+const (
+	MatchPending  = "pending"
+	MatchAccepted = "accepted" // Playlist digabung ke anime kandidat (atau anime pilihan admin)
+	MatchRejected = "rejected" // Playlist dijadikan anime baru
+)
+
+// AnimeMatchReview merepresentasikan tabel 'anime_match_reviews': playlist yang
+// judulnya mirip anime yang sudah ada, tetapi skornya di bawah ambang
+// penggabungan otomatis. Worker melewati playlist ini sampai review diputuskan.
+type AnimeMatchReview struct {
+	ID               int64      `db:"review_id" json:"review_id"`
+	PlaylistID       string     `db:"playlist_id" json:"playlist_id"`
+	ChannelID        string     `db:"channel_id" json:"channel_id"`
+	PlaylistTitle    string     `db:"playlist_title" json:"playlist_title"`
+	ExtractedTitle   string     `db:"extracted_title" json:"extracted_title"`
+	CandidateAnimeID *int       `db:"candidate_anime_id" json:"candidate_anime_id"`
+	CandidateTitle   *string    `db:"candidate_title" json:"candidate_title"`
+	Score            float64    `db:"score" json:"score"`
+	Status           string     `db:"status" json:"status"`
+	ResolvedAnimeID  *int       `db:"resolved_anime_id" json:"resolved_anime_id"`
+	CreatedAt        time.Time  `db:"created_at" json:"created_at"`
+	ResolvedAt       *time.Time `db:"resolved_at" json:"resolved_at"`
 }
@@ -0,0 +1,62 @@
+// Package pipeline menjalankan pekerjaan satu tahap ingest secara paralel
+// dengan jumlah goroutine terbatas, dan mengumpulkan hasil serta error
+// setiap item sesuai urutan input.
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Result adalah hasil pemrosesan satu item.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Map menjalankan fn untuk setiap item dengan paling banyak concurrency
+// goroutine sekaligus dan mengembalikan hasil sesuai urutan items.
+//
+// Item dimulai sesuai urutan. Setelah ctx selesai tidak ada item baru yang
+// dimulai; item yang belum sempat berjalan mendapat error dari ctx. Dengan
+// concurrency 1, item diproses satu per satu persis seperti loop biasa.
+func Map[In, Out any](ctx context.Context, concurrency int, items []In, fn func(context.Context, In) (Out, error)) []Result[Out] {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]Result[Out], len(items))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		sem <- struct{}{}
+		// Diperiksa setelah slot didapat agar pembatalan selalu menghentikan item berikutnya.
+		if ctx.Err() != nil {
+			<-sem
+			for j := i; j < len(items); j++ {
+				results[j].Err = context.Cause(ctx)
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			v, err := fn(ctx, item)
+			results[i] = Result[Out]{Value: v, Err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// Errors mengembalikan semua error di results, sesuai urutan item.
+func Errors[T any](results []Result[T]) []error {
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return errs
+}
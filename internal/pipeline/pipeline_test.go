@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMapConcurrencyLimit(t *testing.T) {
+	tests := []struct {
+		concurrency int
+		wantMax     int
+	}{
+		{concurrency: 0, wantMax: 1},
+		{concurrency: 1, wantMax: 1},
+		{concurrency: 3, wantMax: 3},
+		{concurrency: 8, wantMax: 8},
+		{concurrency: 50, wantMax: 20},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.concurrency), func(t *testing.T) {
+			items := make([]int, 20)
+			var active, peak atomic.Int32
+			Map(context.Background(), tt.concurrency, items, func(ctx context.Context, _ int) (struct{}, error) {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				return struct{}{}, nil
+			})
+			if got := int(peak.Load()); got < 1 || got > tt.wantMax {
+				t.Errorf("peak concurrency = %d, want between 1 and %d", got, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestMapKeepsInputOrder(t *testing.T) {
+	items := []int{5, 1, 4, 2, 3, 0}
+	// Item dengan nilai besar selesai paling akhir, jadi urutan selesai berbeda dari urutan input.
+	results := Map(context.Background(), len(items), items, func(ctx context.Context, n int) (string, error) {
+		time.Sleep(time.Duration(n) * time.Millisecond)
+		return fmt.Sprint("item-", n), nil
+	})
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Value)
+	}
+	want := []string{"item-5", "item-1", "item-4", "item-2", "item-3", "item-0"}
+	if !slices.Equal(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	tests := []struct {
+		name  string
+		items []int
+		want  []string
+	}{
+		{name: "no errors", items: []int{2, 4}, want: nil},
+		{name: "errors in item order", items: []int{1, 2, 3, 4, 5}, want: []string{"item 1: odd", "item 3: odd", "item 5: odd"}},
+		{name: "empty input", items: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Map(context.Background(), 2, tt.items, func(ctx context.Context, n int) (int, error) {
+				if n%2 == 1 {
+					return 0, fmt.Errorf("item %d: %w", n, errOdd)
+				}
+				return n, nil
+			})
+			if len(results) != len(tt.items) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.items))
+			}
+
+			var got []string
+			for _, err := range Errors(results) {
+				if !errors.Is(err, errOdd) {
+					t.Errorf("error %v does not wrap the stage error", err)
+				}
+				got = append(got, err.Error())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Errors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapStopsAfterCancel(t *testing.T) {
+	errStop := errors.New("stop requested")
+	tests := []struct {
+		name        string
+		concurrency int
+		cancelAt    int
+		wantStarted []int
+	}{
+		{name: "sequential", concurrency: 1, cancelAt: 2, wantStarted: []int{0, 1, 2}},
+		{name: "first item", concurrency: 1, cancelAt: 0, wantStarted: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			items := []int{0, 1, 2, 3, 4, 5}
+			var mu sync.Mutex
+			var started []int
+			results := Map(ctx, tt.concurrency, items, func(ctx context.Context, n int) (int, error) {
+				mu.Lock()
+				started = append(started, n)
+				mu.Unlock()
+				if n == tt.cancelAt {
+					cancel(errStop)
+				}
+				return n, nil
+			})
+
+			if !slices.Equal(started, tt.wantStarted) {
+				t.Errorf("started items = %v, want %v", started, tt.wantStarted)
+			}
+			for i, r := range results {
+				skipped := i > tt.cancelAt
+				if skipped && !errors.Is(r.Err, errStop) {
+					t.Errorf("item %d: err = %v, want the cancel cause", i, r.Err)
+				}
+				if !skipped && r.Err != nil {
+					t.Errorf("item %d: unexpected err %v", i, r.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestMapCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var calls atomic.Int32
+	results := Map(ctx, 4, []int{1, 2, 3}, func(ctx context.Context, n int) (int, error) {
+		calls.Add(1)
+		return n, nil
+	})
+	if calls.Load() != 0 {
+		t.Errorf("fn was called %d times after cancel", calls.Load())
+	}
+	if errs := Errors(results); len(errs) != 3 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Errors() = %v, want context.Canceled for every item", errs)
+	}
+}
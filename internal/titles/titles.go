@@ -0,0 +1,228 @@
+// Package titles menormalkan judul anime dan mengukur kemiripannya, agar
+// playlist dari channel dan bahasa berbeda bisa dikelompokkan ke anime yang sama.
+package titles
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// Teks dalam kurung biasanya label bahasa atau judul alternatif.
+	bracketed = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)|【[^】]*】|「[^」]*」`)
+
+	// Penanda season/cour/part dan label rilis yang tidak membedakan anime.
+	markers = regexp.MustCompile(`(?i)\b(?:season\s*\d+|\d+(?:st|nd|rd|th)\s+season|s\d+|cour\s*\d+|part\s*\d+|` +
+		`sub(?:title)?\s*indo(?:nesia)?|eng(?:lish)?\s*sub(?:bed)?|eng(?:lish)?\s*dub(?:bed)?|full\s*episodes?|episodes?)\b|第\s*\d+\s*期`)
+
+	// Tanda kurung dari pola bracketed, dibuang saat isi kurung dipakai sebagai judul.
+	brackets = strings.NewReplacer("[", " ", "]", " ", "(", " ", ")", " ", "【", " ", "】", " ", "「", " ", "」", " ")
+
+	// Pemisah yang tersisa di ujung judul setelah penanda dibuang, misalnya "Judul - ".
+	separators = " -–—:|~/,·"
+
+	// Variasi romanisasi vokal panjang: "Kyou"/"Kyō"/"Kyoo" menjadi "kyo".
+	longVowels = strings.NewReplacer("ou", "o", "oo", "o", "uu", "u", "aa", "a", "ii", "i")
+)
+
+// Normalize mengembalikan bentuk kanonis sebuah judul: lebar Unicode
+// diseragamkan (NFKC), huruf kecil, tanpa diakritik, tanpa isi kurung, tanpa
+// penanda season/cour/part dan label subtitle, vokal panjang romaji diringkas,
+// dan tanda baca diganti spasi. Dua judul dengan bentuk kanonis sama dianggap
+// anime yang sama. Judul yang seluruhnya di dalam kurung, misalnya
+// "【推しの子】", dinormalkan dari isi kurungnya.
+func Normalize(title string) string {
+	if s := normalize(title, false); s != "" {
+		return s
+	}
+	return normalize(title, true)
+}
+
+func normalize(title string, keepBracketed bool) string {
+	s := norm.NFKC.String(title)
+	s = strings.ToLower(s)
+	if folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s); err == nil {
+		s = folded
+	}
+	if keepBracketed {
+		s = brackets.Replace(s)
+	} else {
+		s = bracketed.ReplaceAllString(s, " ")
+	}
+	s = strings.NewReplacer("×", " x ", "&", " and ", "'", "", "’", "").Replace(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	s = markers.ReplaceAllString(s, " ")
+
+	words := strings.Fields(s)
+	for i, w := range words {
+		if w == "wo" {
+			// Partikel を ditulis "wo" atau "o".
+			w = "o"
+		}
+		words[i] = longVowels.Replace(w)
+	}
+	return strings.Join(words, " ")
+}
+
+// Extract mengambil judul anime dari judul playlist untuk disimpan: isi
+// kurung, penanda season/cour/part dan label subtitle dibuang seperti pada
+// Normalize, tetapi huruf besar dan tanda baca di tengah judul dipertahankan.
+// Misalnya "[Sub Indo] Mushoku Tensei: Jobless Reincarnation Season 2 Part 1"
+// menjadi "Mushoku Tensei: Jobless Reincarnation". Judul yang seluruhnya di
+// dalam kurung memakai isi kurungnya.
+func Extract(playlistTitle string) string {
+	s := norm.NFKC.String(playlistTitle)
+	title := cleanTitle(bracketed.ReplaceAllString(s, " "))
+	if title == "" {
+		title = cleanTitle(brackets.Replace(s))
+	}
+	return title
+}
+
+func cleanTitle(s string) string {
+	s = markers.ReplaceAllString(s, " ")
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.Trim(s, separators)
+}
+
+// Similarity mengembalikan kemiripan dua judul antara 0 dan 1. Skor adalah
+// nilai terbesar dari kemiripan trigram huruf (tahan salah ketik) dan rata-rata
+// kecocokan kata (tahan subjudul tambahan, misalnya "Mushoku Tensei" dan
+// "Mushoku Tensei: Jobless Reincarnation").
+func Similarity(a, b string) float64 {
+	a, b = Normalize(a), Normalize(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	return max(trigramDice(a, b), wordOverlap(a, b))
+}
+
+// wordOverlap adalah rata-rata containment (kata yang sama dibagi jumlah kata
+// judul terpendek) dan Jaccard, sehingga judul yang hanya menambah subjudul
+// mendapat skor sedang, bukan sempurna.
+func wordOverlap(a, b string) float64 {
+	wa, wb := wordSet(a), wordSet(b)
+	common := 0
+	for w := range wa {
+		if wb[w] {
+			common++
+		}
+	}
+	if common == 0 {
+		return 0
+	}
+	containment := float64(common) / float64(min(len(wa), len(wb)))
+	jaccard := float64(common) / float64(len(wa)+len(wb)-common)
+	return (containment + jaccard) / 2
+}
+
+func wordSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// trigramDice adalah koefisien Dice atas trigram huruf judul tanpa spasi.
+func trigramDice(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t, n := range ta {
+		common += min(n, tb[t])
+	}
+	total := 0
+	for _, n := range ta {
+		total += n
+	}
+	for _, n := range tb {
+		total += n
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+func trigrams(s string) map[string]int {
+	r := []rune(" " + strings.ReplaceAll(s, " ", "") + " ")
+	grams := make(map[string]int)
+	for i := 0; i+3 <= len(r); i++ {
+		grams[string(r[i:i+3])]++
+	}
+	return grams
+}
+
+// Candidate adalah judul yang bisa dicocokkan, misalnya satu baris anime.
+type Candidate struct {
+	ID    int
+	Title string
+}
+
+// Match adalah kandidat terbaik untuk sebuah judul beserta skornya.
+type Match struct {
+	Candidate
+	Score float64
+}
+
+// BestMatch mengembalikan kandidat yang paling mirip dengan title. ok bernilai
+// false jika tidak ada kandidat dengan skor di atas 0.
+func BestMatch(title string, candidates []Candidate) (best Match, ok bool) {
+	for _, c := range candidates {
+		if score := Similarity(title, c.Title); score > best.Score {
+			best = Match{Candidate: c, Score: score}
+		}
+	}
+	return best, best.Score > 0
+}
+
+var (
+	seasonNumber = regexp.MustCompile(`\bseason\s*(\d+)\b|\b(\d+)(?:st|nd|rd|th)\s+season\b|\bs(\d+)\b|第\s*(\d+)\s*期`)
+	partNumber   = regexp.MustCompile(`\b(?:cour|part)\s*(\d+)\b`)
+)
+
+// Season adalah season dan bagian (cour/part) yang disebut sebuah judul playlist.
+type Season struct {
+	Number int    // 1 jika judul tidak menyebut season
+	Part   int    // 0 jika judul tidak menyebut cour atau part
+	Label  string // Misalnya "Season 2" atau "Season 2 Part 2"
+}
+
+// ParseSeason membaca nomor season dan cour/part dari judul playlist, misalnya
+// "Season 2", "2nd Season", "S2", "第2期", "Cour 2" atau "Part 2".
+func ParseSeason(title string) Season {
+	s := strings.ToLower(norm.NFKC.String(title))
+	season := Season{Number: 1}
+	if m := seasonNumber.FindStringSubmatch(s); m != nil {
+		for _, g := range m[1:] {
+			if n, err := strconv.Atoi(g); err == nil && n > 0 {
+				season.Number = n
+				break
+			}
+		}
+	}
+	if m := partNumber.FindStringSubmatch(s); m != nil {
+		season.Part, _ = strconv.Atoi(m[1])
+	}
+
+	season.Label = fmt.Sprintf("Season %d", season.Number)
+	if season.Part > 0 {
+		season.Label += fmt.Sprintf(" Part %d", season.Part)
+	}
+	return season
+}
@@ -0,0 +1,142 @@
+package titles
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Mushoku Tensei: Jobless Reincarnation", "mushoku tensei jobless reincarnation"},
+		{"[Sub Indo] Mushoku Tensei Season 2", "mushoku tensei"},
+		{"Mushoku Tensei Sub Indo", "mushoku tensei"},
+		{"ＳＰＹ×ＦＡＭＩＬＹ", "spy x family"},
+		{"Spy x Family 2nd Season (English Sub)", "spy x family"},
+		{"Kyōkai no Kanata", "kyokai no kanata"},
+		{"Kyoukai no Kanata", "kyokai no kanata"},
+		{"Kyookai no Kanata", "kyokai no kanata"},
+		{"Pokémon", "pokemon"},
+		{"Kono Subarashii Sekai ni Shukufuku wo!", "kono subarashi sekai ni shukufuku o"},
+		{"Kono Subarashii Sekai ni Shukufuku o", "kono subarashi sekai ni shukufuku o"},
+		{"Kaguya-sama: Love Is War Cour 2", "kaguya sama love is war"},
+		{"Oshi no Ko 第2期", "oshi no ko"},
+		{"【推しの子】", "推しの子"},
+		{"【推しの子】 第2期", "推しの子"},
+		{"【】", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.title); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		min, max float64
+	}{
+		// Contoh dari permintaan fitur: subjudul tambahan masuk rentang review (0.6-0.9).
+		{"subtitle only on one side", "Mushoku Tensei: Jobless Reincarnation", "Mushoku Tensei Sub Indo", 0.74, 0.76},
+		{"width variant", "ＳＰＹ×ＦＡＭＩＬＹ", "Spy x Family", 1, 1},
+		{"diacritics", "Kyōkai no Kanata", "Kyoukai no Kanata", 1, 1},
+		{"long vowel romanization", "Kyō", "Kyoo", 1, 1},
+		{"wo and o", "Shukufuku wo", "Shukufuku o", 1, 1},
+		{"season markers ignored", "Oshi no Ko Season 2", "Oshi no Ko S2", 1, 1},
+		{"typo", "Mushoku Tensei", "Mushoku Tensi", 0.6, 0.99},
+		{"unrelated", "One Piece", "Naruto", 0, 0.1},
+		{"bracket-only title uses its contents", "【推しの子】", "【推しの子】 第2期", 1, 1},
+		{"empty title", "", "One Piece", 0, 0},
+		{"only markers", "Season 2 Sub Indo", "Season 2 Sub Indo", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Similarity(tt.a, tt.b)
+			if got < tt.min || got > tt.max {
+				t.Errorf("Similarity(%q, %q) = %.3f, want between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+			}
+			if back := Similarity(tt.b, tt.a); math.Abs(back-got) > 1e-9 {
+				t.Errorf("Similarity is not symmetric: %.3f vs %.3f", got, back)
+			}
+		})
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	candidates := []Candidate{
+		{ID: 1, Title: "One Piece"},
+		{ID: 2, Title: "Mushoku Tensei: Jobless Reincarnation"},
+		{ID: 3, Title: "Mushoku Tensei"},
+		{ID: 4, Title: "【推しの子】"},
+	}
+	tests := []struct {
+		title     string
+		wantID    int
+		wantScore float64
+		wantOK    bool
+	}{
+		{"[Sub Indo] Mushoku Tensei Season 2", 3, 1, true},
+		{"Mushoku Tensei: Jobless Reincarnation Part 2", 2, 1, true},
+		{"【推しの子】 第2期", 4, 1, true},
+		{"Season 2", 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := BestMatch(tt.title, candidates)
+		if ok != tt.wantOK || got.ID != tt.wantID || got.Score != tt.wantScore {
+			t.Errorf("BestMatch(%q) = (#%d, %.3f, %t), want (#%d, %.3f, %t)", tt.title, got.ID, got.Score, ok, tt.wantID, tt.wantScore, tt.wantOK)
+		}
+	}
+
+	if _, ok := BestMatch("One Piece", nil); ok {
+		t.Error("BestMatch with no candidates reported a match")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"[Sub Indo] Mushoku Tensei Season 2", "Mushoku Tensei"},
+		{"Mushoku Tensei: Jobless Reincarnation Season 2 Part 1 | Full Episodes", "Mushoku Tensei: Jobless Reincarnation"},
+		{"Spy x Family 2nd Season", "Spy x Family"},
+		{"Dr. Stone S3", "Dr. Stone"},
+		{"Kaguya-sama wa Kokurasetai (English Sub) - Part 2", "Kaguya-sama wa Kokurasetai"},
+		{"ＳＰＹ×ＦＡＭＩＬＹ Cour 2", "SPY×FAMILY"},
+		{"【推しの子】 第2期", "推しの子"},
+		{"Bocchi the Rock!", "Bocchi the Rock!"},
+	}
+	for _, tt := range tests {
+		if got := Extract(tt.title); got != tt.want {
+			t.Errorf("Extract(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestParseSeason(t *testing.T) {
+	tests := []struct {
+		title string
+		want  Season
+	}{
+		{"Mushoku Tensei", Season{Number: 1, Label: "Season 1"}},
+		{"Mushoku Tensei Season 2", Season{Number: 2, Label: "Season 2"}},
+		{"Spy x Family 2nd Season", Season{Number: 2, Label: "Season 2"}},
+		{"Re:Zero 3rd Season", Season{Number: 3, Label: "Season 3"}},
+		{"Oshi no Ko S2", Season{Number: 2, Label: "Season 2"}},
+		{"【推しの子】 第2期", Season{Number: 2, Label: "Season 2"}},
+		{"Ｓｅａｓｏｎ ２", Season{Number: 2, Label: "Season 2"}},
+		{"Mushoku Tensei Part 2", Season{Number: 1, Part: 2, Label: "Season 1 Part 2"}},
+		{"Kaguya-sama Season 2 Cour 2", Season{Number: 2, Part: 2, Label: "Season 2 Part 2"}},
+		{"Season 0", Season{Number: 1, Label: "Season 1"}},
+	}
+	for _, tt := range tests {
+		if got := ParseSeason(tt.title); got != tt.want {
+			t.Errorf("ParseSeason(%q) = %+v, want %+v", tt.title, got, tt.want)
+		}
+	}
+}
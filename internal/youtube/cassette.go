@@ -0,0 +1,183 @@
+package youtube
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CassetteMode menentukan apakah Recorder merekam trafik asli atau memutar ulang rekaman.
+type CassetteMode string
+
+const (
+	CassetteRecord CassetteMode = "record"
+	CassetteReplay CassetteMode = "replay"
+)
+
+// Interaction adalah satu pasangan request/response yang terekam.
+type Interaction struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Cassette adalah isi file rekaman.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder adalah http.RoundTripper yang menyimpan trafik API ke file cassette
+// (mode record) atau melayani request dari cassette tersebut (mode replay).
+// API key selalu dibuang dari URL dan header sebelum disimpan maupun dicocokkan.
+type Recorder struct {
+	mode CassetteMode
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	// replayed mencatat berapa kali setiap request sudah diputar, agar request
+	// yang sama (misalnya saat retry) dilayani sesuai urutan rekaman.
+	replayed map[string]int
+}
+
+// NewRecorder membuat Recorder untuk file cassette di path. Pada mode record,
+// next dipakai untuk mengirim request asli (nil berarti http.DefaultTransport).
+// Pada mode replay, cassette harus sudah ada.
+func NewRecorder(path string, mode CassetteMode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &Recorder{mode: mode, path: path, next: next, replayed: make(map[string]int)}
+
+	switch mode {
+	case CassetteRecord:
+	case CassetteReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	return r, nil
+}
+
+// RoundTrip mengimplementasikan http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == CassetteReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Method:     req.Method,
+		URL:        sanitizeURL(req.URL),
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       string(body),
+	})
+	// Disimpan setiap kali agar rekaman tetap utuh walau worker berhenti di tengah jalan.
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	reqURL := sanitizeURL(req.URL)
+	key := req.Method + " " + reqURL
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []Interaction
+	for _, in := range r.cassette.Interactions {
+		if in.Method == req.Method && in.URL == reqURL {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette %s has no interaction for %s", r.path, key)
+	}
+
+	// Setelah semua rekaman terpakai, interaksi terakhir diputar berulang.
+	n := r.replayed[key]
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	r.replayed[key]++
+	in := matches[n]
+
+	header := in.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode:    in.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(in.Body))),
+		ContentLength: int64(len(in.Body)),
+		Request:       req,
+	}, nil
+}
+
+// save menulis cassette ke disk secara atomik. Harus dipanggil dengan mu terkunci.
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// sanitizeURL mengembalikan path dan query request tanpa host dan tanpa API key,
+// dengan urutan parameter yang stabil.
+func sanitizeURL(u *url.URL) string {
+	q := u.Query()
+	q.Del("key")
+	if len(q) == 0 {
+		return u.Path
+	}
+	return u.Path + "?" + q.Encode()
+}
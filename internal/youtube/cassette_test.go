@@ -0,0 +1,89 @@
+package youtube
+
+import (
+	"alyo/internal/youtube/youtubetest"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAPIKey = "AIzaSy-test-secret-key"
+
+func TestRecorderStripsAPIKey(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewRecorder(path, CassetteRecord, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Client mengirim key lewat header X-Goog-Api-Key.
+	client := NewClient([]string{testAPIKey}, WithBaseURL(srv.URL), WithHTTPClient(&http.Client{Transport: recorder}))
+	if _, err := client.GetPlaylistsForChannel(context.Background(), "UCxxnxya_32jcKj4yN1_kD7A"); err != nil {
+		t.Fatal(err)
+	}
+	// Request lain mungkin masih memakai parameter key di query string.
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/channels?part=snippet&id=UCxxnxya_32jcKj4yN1_kD7A&key="+testAPIKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Goog-Api-Key", testAPIKey)
+	resp, err := (&http.Client{Transport: recorder}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(testAPIKey)) {
+		t.Errorf("cassette contains the API key:\n%s", data)
+	}
+	if bytes.Contains(bytes.ToLower(data), []byte("x-goog-api-key")) {
+		t.Errorf("cassette contains the X-Goog-Api-Key header:\n%s", data)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 2 {
+		t.Fatalf("got %d interactions, want 2", len(cassette.Interactions))
+	}
+	for _, in := range cassette.Interactions {
+		u, err := url.Parse(in.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Query().Has("key") {
+			t.Errorf("interaction %s keeps the key query parameter", in.URL)
+		}
+		if strings.Contains(in.URL, srv.URL) {
+			t.Errorf("interaction %s keeps the host", in.URL)
+		}
+	}
+
+	// Rekaman tetap cocok saat diputar ulang dengan key lain.
+	replayer, err := NewRecorder(path, CassetteReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = NewClient([]string{"another-key"}, WithBaseURL(srv.URL), WithHTTPClient(&http.Client{Transport: replayer}))
+	playlists, err := client.GetPlaylistsForChannel(context.Background(), "UCxxnxya_32jcKj4yN1_kD7A")
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(playlists) != 3 {
+		t.Errorf("replayed %d playlists, want 3", len(playlists))
+	}
+}
@@ -1,31 +1,100 @@
 package youtube
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"syscall"
 	"time"
 )
 
 const (
-	apiBaseURL = "https://www.googleapis.com/youtube/v3"
+	// DefaultBaseURL adalah base URL YouTube Data API v3 yang asli.
+	DefaultBaseURL = "https://www.googleapis.com/youtube/v3"
 )
 
 // Client adalah klien untuk berinteraksi dengan YouTube Data API v3.
 type Client struct {
-	apiKey     string
+	keys       *keyPool
+	baseURL    string
 	httpClient *http.Client
+
+	// Pengaturan retry untuk kegagalan sementara (5xx, timeout, koneksi putus).
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+
+	quota   *QuotaTracker
+	etags   ETagCache
+	limiter *rateLimiter
+}
+
+// Option mengatur konfigurasi opsional Client.
+type Option func(*Client)
+
+// WithBaseURL mengganti base URL API, misalnya ke server YouTube palsu untuk pengujian.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
 }
 
-// NewClient membuat instance baru dari YouTube Client.
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
+// WithHTTPClient mengganti http.Client yang dipakai untuk memanggil API.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithQuotaTracker memasang QuotaTracker sehingga setiap panggilan API dihitung
+// terhadap budget harian.
+func WithQuotaTracker(t *QuotaTracker) Option {
+	return func(c *Client) {
+		c.quota = t
+	}
+}
+
+// NewClient membuat instance baru dari YouTube Client dengan satu atau lebih
+// API key. Jika sebuah key kehabisan quota atau ditolak, Client otomatis
+// berpindah ke key berikutnya.
+func NewClient(apiKeys []string, opts ...Option) *Client {
+	c := &Client{
+		keys:    newKeyPool(apiKeys),
+		baseURL: DefaultBaseURL,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		maxRetries: 4,
+		baseDelay:  time.Second,
+		maxDelay:   30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// QuotaUsage mengembalikan pemakaian quota hari ini, atau false jika Client
+// tidak memiliki QuotaTracker.
+func (c *Client) QuotaUsage(ctx context.Context) (usage QuotaUsage, ok bool, err error) {
+	if c.quota == nil {
+		return QuotaUsage{}, false, nil
 	}
+	usage, err = c.quota.Usage(ctx)
+	return usage, true, err
+}
+
+// KeyStatuses mengembalikan kondisi dan pemakaian setiap API key.
+func (c *Client) KeyStatuses() []KeyStatus {
+	return c.keys.statuses(QuotaDay(time.Now()))
 }
 
 // --- Structs untuk Parsing JSON Response ---
@@ -52,16 +121,22 @@ type PlaylistItem struct {
 }
 
 type PlaylistSnippet struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	ChannelID   string `json:"channelId"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	ChannelID    string `json:"channelId"`
+	ChannelTitle string `json:"channelTitle"`
 }
 
 type PlaylistItemListResponse struct {
 	NextPageToken string      `json:"nextPageToken"`
+	PageInfo      PageInfo    `json:"pageInfo"`
 	Items         []VideoItem `json:"items"`
 }
 
+type PageInfo struct {
+	TotalResults int `json:"totalResults"`
+}
+
 type VideoItem struct {
 	Snippet VideoSnippet `json:"snippet"`
 }
@@ -91,36 +166,211 @@ type VideoListResponse struct {
 }
 
 type VideoDetailItem struct {
-	ID         string     `json:"id"`
-	Statistics Statistics `json:"statistics"`
+	ID             string         `json:"id"`
+	Statistics     Statistics     `json:"statistics"`
+	ContentDetails ContentDetails `json:"contentDetails"`
+	Status         VideoStatus    `json:"status"`
 }
 
 type Statistics struct {
-	ViewCount string `json:"viewCount"`
+	ViewCount    string `json:"viewCount"`
+	LikeCount    string `json:"likeCount"`
+	CommentCount string `json:"commentCount"`
 }
 
-// GetPlaylistsForChannel mengambil semua playlist dari sebuah channel.
-func (c *Client) GetPlaylistsForChannel(channelID string) ([]PlaylistItem, error) {
-	var allPlaylists []PlaylistItem
-	pageToken := ""
+type ContentDetails struct {
+	Duration          string             `json:"duration"`
+	RegionRestriction *RegionRestriction `json:"regionRestriction"`
+}
 
-	for {
-		url := fmt.Sprintf("%s/playlists?part=snippet&channelId=%s&maxResults=50&key=%s&pageToken=%s",
-			apiBaseURL, channelID, c.apiKey, pageToken)
+// RegionRestriction berisi kode negara ISO 3166-1 alpha-2. Jika Allowed terisi,
+// video hanya bisa ditonton di negara tersebut; jika Blocked terisi, video
+// bisa ditonton di mana saja kecuali negara tersebut.
+type RegionRestriction struct {
+	Allowed []string `json:"allowed"`
+	Blocked []string `json:"blocked"`
+}
+
+type VideoStatus struct {
+	UploadStatus  string `json:"uploadStatus"`
+	PrivacyStatus string `json:"privacyStatus"`
+	Embeddable    bool   `json:"embeddable"`
+}
+
+// get mengirim GET ke endpoint API dan men-decode respons JSON ke out.
+// Kegagalan sementara dicoba ulang dengan exponential backoff dan jitter.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	_, err := c.getConditional(ctx, endpoint, params, out)
+	return err
+}
 
-		resp, err := c.httpClient.Get(url)
+// getConditional sama seperti get, tetapi memakai ETag tersimpan (jika ada
+// ETagCache) dan melaporkan notModified saat out diisi dari cache karena 304.
+func (c *Client) getConditional(ctx context.Context, endpoint string, params url.Values, out interface{}) (notModified bool, err error) {
+	var cached *cachedResponse
+	if c.etags != nil {
+		key := resourceKey(endpoint, params)
+		cached = &cachedResponse{key: key}
+		if skip, _ := ctx.Value(skipETagsKey{}).(bool); !skip {
+			etag, body, err := c.etags.GetCachedResponse(ctx, key)
+			if err != nil {
+				return false, fmt.Errorf("failed to load cached response: %w", err)
+			}
+			cached.etag, cached.body = etag, body
+		}
+	}
+
+	reqURL := fmt.Sprintf("%s/%s?%s", c.baseURL, endpoint, params.Encode())
+
+	attempt := 0
+	for {
+		if c.limiter != nil {
+			if err := c.limiter.wait(ctx); err != nil {
+				return false, err
+			}
+		}
+		day := QuotaDay(time.Now())
+		key, err := c.keys.acquire(day)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
+			return false, err
+		}
+		// YouTube menagih quota untuk setiap request, termasuk yang gagal dan dicoba ulang.
+		if c.quota != nil {
+			if err := c.quota.reserve(ctx, endpoint, key.label); err != nil {
+				return false, err
+			}
+		}
+		countUnits(ctx, cost(endpoint))
+
+		notModified, err = c.do(ctx, reqURL, key.value, cached, out)
+		switch {
+		case err == nil:
+			return notModified, nil
+		case ctx.Err() != nil:
+			return false, err
+		case errors.Is(err, ErrQuotaExceeded):
+			log.Printf("WARN: YouTube API %s exhausted its quota for %s, rotating to the next key", key.label, day)
+			c.keys.markExhausted(key, day, err)
+			continue
+		case errors.Is(err, ErrKeyInvalid):
+			log.Printf("WARN: YouTube API %s was rejected as invalid, disabling it", key.label)
+			c.keys.markRevoked(key, err)
+			continue
+		case !isTemporary(err) || attempt >= c.maxRetries:
+			return false, err
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("youtube api returned non-200 status: %s", resp.Status)
+		attempt++
+		select {
+		case <-time.After(c.backoff(attempt)):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) do(ctx context.Context, reqURL string, key string, cached *cachedResponse, out interface{}) (notModified bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return false, err
+	}
+	// Key dikirim lewat header, bukan query string, agar tidak pernah ikut
+	// tercetak di URL pada pesan error maupun log.
+	req.Header.Set("X-Goog-Api-Key", key)
+	if cached != nil && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil && cached.etag != "" {
+		if err := json.Unmarshal(cached.body, out); err != nil {
+			return false, fmt.Errorf("failed to decode cached response: %w", err)
+		}
+		return true, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, parseAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if cached != nil {
+		if etag := responseETag(resp, body); etag != "" {
+			if err := c.etags.SaveCachedResponse(ctx, cached.key, etag, body); err != nil {
+				return false, fmt.Errorf("failed to save cached response: %w", err)
+			}
+		}
+	}
+	return false, nil
+}
+
+// responseETag mengambil ETag dari header respons, atau dari field "etag" di body.
+func responseETag(resp *http.Response, body []byte) string {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag
+	}
+	var envelope struct {
+		ETag string `json:"etag"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.ETag == "" {
+		return ""
+	}
+	return `"` + envelope.ETag + `"`
+}
+
+// backoff menghitung jeda sebelum percobaan ke-attempt (dimulai dari 1).
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	// Separuh jeda tetap, separuh acak, agar retry dari banyak goroutine tidak serentak.
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// isTemporary melaporkan apakah err adalah kegagalan sementara yang layak dicoba ulang.
+func isTemporary(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.temporary()
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// GetPlaylistsForChannel mengambil semua playlist dari sebuah channel.
+func (c *Client) GetPlaylistsForChannel(ctx context.Context, channelID string) ([]PlaylistItem, error) {
+	var allPlaylists []PlaylistItem
+	pageToken := ""
+
+	for {
+		params := url.Values{
+			"part":       {"snippet"},
+			"channelId":  {channelID},
+			"maxResults": {"50"},
+			"pageToken":  {pageToken},
 		}
 
 		var response PlaylistListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			return nil, fmt.Errorf("failed to decode playlist response: %w", err)
+		if err := c.get(ctx, "playlists", params, &response); err != nil {
+			return nil, fmt.Errorf("failed to fetch playlists: %w", err)
 		}
 
 		allPlaylists = append(allPlaylists, response.Items...)
@@ -134,31 +384,79 @@ func (c *Client) GetPlaylistsForChannel(channelID string) ([]PlaylistItem, error
 	return allPlaylists, nil
 }
 
-// GetVideosForPlaylist mengambil semua video dari sebuah playlist.
-func (c *Client) GetVideosForPlaylist(playlistID string) ([]VideoItem, error) {
-	var allVideos []VideoItem
-	pageToken := ""
+// GetPlaylist mengambil satu playlist berdasarkan ID-nya.
+// Mengembalikan ErrPlaylistNotFound jika playlist tidak ada.
+func (c *Client) GetPlaylist(ctx context.Context, playlistID string) (*PlaylistItem, error) {
+	params := url.Values{
+		"part": {"snippet"},
+		"id":   {playlistID},
+	}
 
-	for {
-		url := fmt.Sprintf("%s/playlistItems?part=snippet&playlistId=%s&maxResults=50&key=%s&pageToken=%s",
-			apiBaseURL, playlistID, c.apiKey, pageToken)
+	var response PlaylistListResponse
+	if err := c.get(ctx, "playlists", params, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch playlist: %w", err)
+	}
+	if len(response.Items) == 0 {
+		return nil, fmt.Errorf("playlist %s: %w", playlistID, ErrPlaylistNotFound)
+	}
+	return &response.Items[0], nil
+}
 
-		resp, err := c.httpClient.Get(url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch videos: %w", err)
-		}
-		defer resp.Body.Close()
+func playlistItemsParams(playlistID, pageToken string) url.Values {
+	return url.Values{
+		"part":       {"snippet"},
+		"playlistId": {playlistID},
+		"maxResults": {"50"},
+		"pageToken":  {pageToken},
+	}
+}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("youtube api returned non-200 status: %s", resp.Status)
-		}
+// GetVideosForPlaylist mengambil semua video dari sebuah playlist.
+// Mengembalikan ErrNotModified jika playlist tidak berubah sejak diambil terakhir kali.
+func (c *Client) GetVideosForPlaylist(ctx context.Context, playlistID string) ([]VideoItem, error) {
+	page, err := c.GetVideosForPlaylistFrom(ctx, playlistID, "")
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// PlaylistItems adalah hasil GetVideosForPlaylistFrom.
+type PlaylistItems struct {
+	Items []VideoItem
+	// LastPageToken adalah token halaman terakhir yang diambil ("" jika hanya
+	// ada satu halaman). Simpan untuk melanjutkan sinkronisasi berikutnya dari sana.
+	LastPageToken string
+	// TotalResults adalah jumlah item di seluruh playlist menurut YouTube.
+	TotalResults int
+}
+
+// GetVideosForPlaylistFrom mengambil video playlist mulai dari halaman
+// pageToken sampai halaman terakhir. Video baru selalu ditambahkan di akhir
+// playlist, sehingga melanjutkan dari halaman terakhir sinkronisasi sebelumnya
+// cukup untuk menemukan episode baru.
+//
+// Mengembalikan ErrNotModified jika halaman awal tidak berubah sejak diambil
+// terakhir kali. Setiap halaman memuat totalResults dan nextPageToken, jadi 304
+// di halaman awal berarti tidak ada item yang ditambahkan setelahnya.
+func (c *Client) GetVideosForPlaylistFrom(ctx context.Context, playlistID, pageToken string) (PlaylistItems, error) {
+	var result PlaylistItems
+	first := true
 
+	for {
 		var response PlaylistItemListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			return nil, fmt.Errorf("failed to decode video response: %w", err)
+		notModified, err := c.getConditional(ctx, "playlistItems", playlistItemsParams(playlistID, pageToken), &response)
+		if err != nil {
+			return PlaylistItems{}, fmt.Errorf("failed to fetch videos: %w", err)
+		}
+		if notModified && first {
+			return PlaylistItems{}, ErrNotModified
 		}
+		first = false
 
-		allVideos = append(allVideos, response.Items...)
+		result.Items = append(result.Items, response.Items...)
+		result.LastPageToken = pageToken
+		result.TotalResults = response.PageInfo.TotalResults
 
 		if response.NextPageToken == "" {
 			break
@@ -166,10 +464,11 @@ func (c *Client) GetVideosForPlaylist(playlistID string) ([]VideoItem, error) {
 		pageToken = response.NextPageToken
 	}
 
-	return allVideos, nil
+	return result, nil
 }
 
-func (c *Client) GetVideoDetails(videoIDs []string) ([]VideoDetailItem, error) {
+// GetVideoDetails mengambil statistik, durasi, status privasi dan batasan region untuk setiap video.
+func (c *Client) GetVideoDetails(ctx context.Context, videoIDs []string) ([]VideoDetailItem, error) {
 	if len(videoIDs) == 0 {
 		return nil, nil
 	}
@@ -185,23 +484,15 @@ func (c *Client) GetVideoDetails(videoIDs []string) ([]VideoDetailItem, error) {
 			end = len(videoIDs)
 		}
 		chunk := videoIDs[i:end]
-		ids := strings.Join(chunk, ",")
 
-		url := fmt.Sprintf("%s/videos?part=statistics&id=%s&key=%s", apiBaseURL, ids, c.apiKey)
-
-		resp, err := c.httpClient.Get(url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch video details: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("youtube api returned non-200 status for video details: %s", resp.Status)
+		params := url.Values{
+			"part": {"statistics,contentDetails,status"},
+			"id":   {strings.Join(chunk, ",")},
 		}
 
 		var response VideoListResponse
-		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-			return nil, fmt.Errorf("failed to decode video details response: %w", err)
+		if err := c.get(ctx, "videos", params, &response); err != nil {
+			return nil, fmt.Errorf("failed to fetch video details: %w", err)
 		}
 
 		allVideoDetails = append(allVideoDetails, response.Items...)
@@ -210,22 +501,15 @@ func (c *Client) GetVideoDetails(videoIDs []string) ([]VideoDetailItem, error) {
 	return allVideoDetails, nil
 }
 
-func (c *Client) GetChannelProfilePicture(channelID string) (string, error) {
-	url := fmt.Sprintf("%s/channels?part=snippet&id=%s&key=%s", apiBaseURL, channelID, c.apiKey)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch channel details: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("youtube api returned non-200 status for channel details: %s", resp.Status)
+func (c *Client) GetChannelProfilePicture(ctx context.Context, channelID string) (string, error) {
+	params := url.Values{
+		"part": {"snippet"},
+		"id":   {channelID},
 	}
 
 	var response ChannelListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode channel details response: %w", err)
+	if err := c.get(ctx, "channels", params, &response); err != nil {
+		return "", fmt.Errorf("failed to fetch channel details: %w", err)
 	}
 
 	if len(response.Items) > 0 {
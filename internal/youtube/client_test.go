@@ -0,0 +1,163 @@
+package youtube
+
+import (
+	"alyo/internal/youtube/youtubetest"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testPlaylist adalah playlist di fixture bawaan youtubetest.
+const testPlaylist = "PL3640fc13746ca440b0681793b173d1ab"
+
+// newTestClient membuat Client ke srv dengan jeda retry sangat pendek.
+func newTestClient(srv *youtubetest.Server, keys ...string) *Client {
+	if len(keys) == 0 {
+		keys = []string{testAPIKey}
+	}
+	c := NewClient(keys, WithBaseURL(srv.URL))
+	c.baseDelay, c.maxDelay = time.Millisecond, 2*time.Millisecond
+	return c
+}
+
+func TestClientRetriesTemporaryErrors(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	srv.FailNext("playlists", http.StatusServiceUnavailable, "backendError")
+	srv.FailNext("playlists", http.StatusInternalServerError, "backendError")
+
+	playlist, err := newTestClient(srv).GetPlaylist(context.Background(), testPlaylist)
+	if err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	if playlist.ID != testPlaylist {
+		t.Errorf("playlist = %s, want %s", playlist.ID, testPlaylist)
+	}
+	if got := srv.Requests("playlists"); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+}
+
+func TestClientGivesUpAfterMaxRetries(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv)
+	c.maxRetries = 2
+	for range 4 {
+		srv.FailNext("playlists", http.StatusServiceUnavailable, "backendError")
+	}
+
+	_, err := c.GetPlaylist(context.Background(), testPlaylist)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GetPlaylist error = %v, want a 503 APIError", err)
+	}
+	if got := srv.Requests("playlists"); got != 3 {
+		t.Errorf("requests = %d, want 3 (one attempt and two retries)", got)
+	}
+}
+
+func TestClientStopsBackoffWhenContextEnds(t *testing.T) {
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv)
+	c.baseDelay, c.maxDelay = time.Hour, time.Hour
+	srv.FailNext("playlists", http.StatusServiceUnavailable, "backendError")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetPlaylist(ctx, testPlaylist)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetPlaylist error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetPlaylist returned after %s, want it to stop waiting when the context ends", elapsed)
+	}
+	if got := srv.Requests("playlists"); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestClientTypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		reason string
+		want   error
+	}{
+		{http.StatusForbidden, "quotaExceeded", ErrQuotaExceeded},
+		{http.StatusForbidden, "rateLimitExceeded", ErrRateLimitExceeded},
+		{http.StatusNotFound, "playlistNotFound", ErrPlaylistNotFound},
+		{http.StatusBadRequest, "keyInvalid", ErrKeyInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			srv := youtubetest.NewServer()
+			defer srv.Close()
+			c := newTestClient(srv)
+			// rateLimitExceeded dicoba ulang, jadi gagalkan setiap percobaan.
+			for range c.maxRetries + 1 {
+				srv.FailNext("playlists", tt.status, tt.reason)
+			}
+
+			_, err := c.GetPlaylist(context.Background(), testPlaylist)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("GetPlaylist error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantReason string
+		temporary  bool
+	}{
+		{
+			name:       "reason from errors",
+			status:     http.StatusForbidden,
+			body:       `{"error":{"code":403,"message":"quota","errors":[{"reason":"quotaExceeded"}]}}`,
+			wantReason: "quotaExceeded",
+		},
+		{
+			name:       "invalid key reported as badRequest",
+			status:     http.StatusBadRequest,
+			body:       `{"error":{"code":400,"message":"API key not valid.","errors":[{"reason":"badRequest"}],"details":[{"reason":"API_KEY_INVALID"}]}}`,
+			wantReason: "keyInvalid",
+		},
+		{
+			name:       "rate limit is temporary",
+			status:     http.StatusForbidden,
+			body:       `{"error":{"code":403,"message":"slow down","errors":[{"reason":"userRateLimitExceeded"}]}}`,
+			wantReason: "userRateLimitExceeded",
+			temporary:  true,
+		},
+		{
+			name:      "server error without JSON body",
+			status:    http.StatusBadGateway,
+			body:      `<html>Bad Gateway</html>`,
+			temporary: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(tt.status)
+			rec.WriteString(tt.body)
+
+			apiErr := parseAPIError(rec.Result())
+			if apiErr.StatusCode != tt.status || apiErr.Reason != tt.wantReason {
+				t.Errorf("parseAPIError = status %d reason %q, want status %d reason %q", apiErr.StatusCode, apiErr.Reason, tt.status, tt.wantReason)
+			}
+			if got := apiErr.temporary(); got != tt.temporary {
+				t.Errorf("temporary() = %v, want %v", got, tt.temporary)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package youtube
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// ParseDuration mengubah durasi ISO 8601 dari contentDetails.duration
+// (misalnya "PT23M40S" atau "P1DT2H") menjadi time.Duration.
+func ParseDuration(iso string) (time.Duration, error) {
+	m := isoDurationRe.FindStringSubmatch(iso)
+	if m == nil || iso == "P" || iso == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", iso)
+	}
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+1] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", iso, err)
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
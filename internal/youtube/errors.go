@@ -0,0 +1,97 @@
+package youtube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error sentinel untuk alasan error YouTube yang perlu dibedakan oleh pemanggil.
+// Gunakan errors.Is untuk memeriksa *APIError terhadap nilai-nilai ini.
+var (
+	ErrQuotaExceeded     = errors.New("youtube: daily quota exceeded")
+	ErrRateLimitExceeded = errors.New("youtube: rate limit exceeded")
+	ErrPlaylistNotFound  = errors.New("youtube: playlist not found")
+	ErrKeyInvalid        = errors.New("youtube: api key invalid")
+)
+
+// APIError merepresentasikan respons error dari YouTube Data API.
+type APIError struct {
+	StatusCode int
+	Reason     string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("youtube api returned status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("youtube api returned status %d (%s): %s", e.StatusCode, e.Reason, e.Message)
+}
+
+// Is mencocokkan APIError dengan error sentinel berdasarkan reason-nya.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrQuotaExceeded:
+		return e.Reason == "quotaExceeded" || e.Reason == "dailyLimitExceeded"
+	case ErrRateLimitExceeded:
+		return e.Reason == "rateLimitExceeded" || e.Reason == "userRateLimitExceeded"
+	case ErrPlaylistNotFound:
+		return e.Reason == "playlistNotFound"
+	case ErrKeyInvalid:
+		return e.Reason == "keyInvalid" || e.Reason == "keyExpired"
+	}
+	return false
+}
+
+// temporary melaporkan apakah error ini layak dicoba ulang.
+func (e *APIError) temporary() bool {
+	if e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return errors.Is(e, ErrRateLimitExceeded)
+}
+
+type errorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Errors  []struct {
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"errors"`
+		Details []struct {
+			Reason string `json:"reason"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// parseAPIError membaca body respons non-200 dan mengubahnya menjadi *APIError.
+func parseAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: resp.Status}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return apiErr
+	}
+
+	var parsed errorResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return apiErr
+	}
+	if parsed.Error.Message != "" {
+		apiErr.Message = parsed.Error.Message
+	}
+	if len(parsed.Error.Errors) > 0 {
+		apiErr.Reason = parsed.Error.Errors[0].Reason
+	}
+	// Key yang tidak valid dilaporkan sebagai "badRequest" dengan detail API_KEY_INVALID.
+	for _, d := range parsed.Error.Details {
+		if d.Reason == "API_KEY_INVALID" {
+			apiErr.Reason = "keyInvalid"
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,67 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrNotModified dikembalikan oleh GetVideosForPlaylist jika YouTube menjawab
+// 304 Not Modified untuk halaman pertama playlist, artinya isi playlist tidak
+// berubah sejak sinkronisasi terakhir.
+var ErrNotModified = errors.New("youtube: resource not modified")
+
+// ETagCache menyimpan ETag beserta body respons terakhir untuk setiap resource,
+// sehingga Client bisa mengirim request kondisional (If-None-Match) dan tetap
+// memakai body lama saat YouTube menjawab 304.
+type ETagCache interface {
+	GetCachedResponse(ctx context.Context, resourceKey string) (etag string, body []byte, err error)
+	SaveCachedResponse(ctx context.Context, resourceKey string, etag string, body []byte) error
+	DeleteCachedResponse(ctx context.Context, resourceKey string) error
+}
+
+// WithETagCache mengaktifkan request kondisional dengan ETag yang disimpan di cache.
+func WithETagCache(cache ETagCache) Option {
+	return func(c *Client) {
+		c.etags = cache
+	}
+}
+
+type skipETagsKey struct{}
+
+// WithoutETags mengembalikan context turunan ctx yang membuat request Client
+// tidak mengirim ETag tersimpan, sehingga YouTube selalu menjawab dengan isi
+// lengkap. Respons baru tetap disimpan ke cache untuk request berikutnya.
+func WithoutETags(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipETagsKey{}, true)
+}
+
+// cachedResponse adalah respons tersimpan untuk satu resource.
+type cachedResponse struct {
+	key  string
+	etag string
+	body []byte
+}
+
+// resourceKey membentuk kunci cache dari endpoint dan parameter request (tanpa API key).
+func resourceKey(endpoint string, params url.Values) string {
+	q := make(url.Values, len(params))
+	for k, v := range params {
+		if k != "key" {
+			q[k] = v
+		}
+	}
+	return endpoint + "?" + q.Encode()
+}
+
+// InvalidatePlaylist menghapus ETag halaman playlist pada pageToken, yaitu
+// halaman pertama yang diminta secara kondisional oleh run berikutnya ("" untuk
+// halaman pertama playlist, atau LastPageToken pada sinkronisasi incremental).
+// Dengan begitu run berikutnya memproses ulang playlist itu walaupun YouTube
+// menjawab 304. Dipanggil saat pemrosesan playlist gagal setelah datanya diambil.
+func (c *Client) InvalidatePlaylist(ctx context.Context, playlistID, pageToken string) error {
+	if c.etags == nil {
+		return nil
+	}
+	return c.etags.DeleteCachedResponse(ctx, resourceKey("playlistItems", playlistItemsParams(playlistID, pageToken)))
+}
@@ -0,0 +1,162 @@
+package youtube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status kesehatan sebuah API key.
+const (
+	KeyHealthy   = "healthy"
+	KeyExhausted = "exhausted"
+	KeyRevoked   = "revoked"
+)
+
+// KeyStatus adalah ringkasan kondisi satu API key. Key tidak pernah
+// ditampilkan; Label adalah sidik jari pendek yang tidak bisa dibalik.
+type KeyStatus struct {
+	Label     string     `json:"label"`
+	State     string     `json:"state"`
+	Calls     int64      `json:"calls"`
+	LastError string     `json:"last_error,omitempty"`
+	LastUsed  *time.Time `json:"last_used,omitempty"`
+}
+
+type apiKey struct {
+	value string
+	label string
+
+	// exhaustedDay adalah hari quota saat key kehabisan quota; key sehat lagi di hari berikutnya.
+	exhaustedDay string
+	revoked      bool
+	calls        int64
+	lastError    string
+	lastUsed     time.Time
+}
+
+// keyPool menyimpan beberapa API key dan memilih key yang masih sehat.
+// Key yang sedang dipakai terus dipakai sampai kehabisan quota atau ditolak.
+type keyPool struct {
+	mu      sync.Mutex
+	keys    []*apiKey
+	current int
+}
+
+func newKeyPool(values []string) *keyPool {
+	p := &keyPool{}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		p.keys = append(p.keys, &apiKey{value: v, label: KeyLabel(v)})
+	}
+	return p
+}
+
+// ParseAPIKeys mengambil daftar key unik dari list (isi YOUTUBE_API_KEYS,
+// dipisah koma), atau dari single (isi YOUTUBE_API_KEY) jika list kosong.
+func ParseAPIKeys(list, single string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, k := range strings.Split(list, ",") {
+		if k = strings.TrimSpace(k); k != "" && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	if single = strings.TrimSpace(single); len(keys) == 0 && single != "" {
+		keys = append(keys, single)
+	}
+	return keys
+}
+
+// KeyLabel mengembalikan sidik jari pendek sebuah API key yang aman untuk log.
+func KeyLabel(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "key-" + hex.EncodeToString(sum[:4])
+}
+
+// acquire memilih key sehat untuk hari quota day.
+func (p *keyPool) acquire(day string) (*apiKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.keys) == 0 {
+		return nil, fmt.Errorf("%w: no API keys configured", ErrKeyInvalid)
+	}
+
+	exhausted := 0
+	for i := 0; i < len(p.keys); i++ {
+		idx := (p.current + i) % len(p.keys)
+		k := p.keys[idx]
+		switch {
+		case k.revoked:
+		case k.exhaustedDay == day:
+			exhausted++
+		default:
+			p.current = idx
+			k.calls++
+			k.lastUsed = time.Now()
+			return k, nil
+		}
+	}
+	revoked := len(p.keys) - exhausted
+	if exhausted > 0 {
+		return nil, fmt.Errorf("%w: no usable API keys for %s (%d exhausted, %d revoked)", ErrQuotaExceeded, day, exhausted, revoked)
+	}
+	return nil, fmt.Errorf("%w: all %d API keys revoked", ErrKeyInvalid, revoked)
+}
+
+// markExhausted menandai key kehabisan quota untuk hari day dan berpindah ke key berikutnya.
+func (p *keyPool) markExhausted(k *apiKey, day string, cause error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.exhaustedDay = day
+	k.lastError = cause.Error()
+	p.rotate(k)
+}
+
+// markRevoked menandai key tidak valid secara permanen dan berpindah ke key berikutnya.
+func (p *keyPool) markRevoked(k *apiKey, cause error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	k.revoked = true
+	k.lastError = cause.Error()
+	p.rotate(k)
+}
+
+// rotate memindahkan key aktif ke key setelah k. Harus dipanggil dengan mu terkunci.
+func (p *keyPool) rotate(k *apiKey) {
+	if p.keys[p.current] == k {
+		p.current = (p.current + 1) % len(p.keys)
+	}
+}
+
+// statuses mengembalikan kondisi semua key untuk hari quota day.
+func (p *keyPool) statuses(day string) []KeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]KeyStatus, 0, len(p.keys))
+	for _, k := range p.keys {
+		status := KeyStatus{Label: k.label, State: KeyHealthy, Calls: k.calls, LastError: k.lastError}
+		switch {
+		case k.revoked:
+			status.State = KeyRevoked
+		case k.exhaustedDay == day:
+			status.State = KeyExhausted
+		}
+		if !k.lastUsed.IsZero() {
+			lastUsed := k.lastUsed
+			status.LastUsed = &lastUsed
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
@@ -0,0 +1,135 @@
+package youtube
+
+import (
+	"alyo/internal/youtube/youtubetest"
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const (
+	testKeyOne = "AIzaSy-first-secret-key"
+	testKeyTwo = "AIzaSy-second-secret-key"
+)
+
+// captureLog mengalihkan output log standar selama test berjalan.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return &buf
+}
+
+// keyStates memetakan label setiap key ke kondisinya.
+func keyStates(c *Client) map[string]string {
+	states := make(map[string]string)
+	for _, st := range c.KeyStatuses() {
+		states[st.Label] = st.State
+	}
+	return states
+}
+
+func TestClientRotatesExhaustedKey(t *testing.T) {
+	captureLog(t)
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv, testKeyOne, testKeyTwo)
+	srv.FailNext("playlists", http.StatusForbidden, "quotaExceeded")
+
+	if _, err := c.GetPlaylist(context.Background(), testPlaylist); err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	states := keyStates(c)
+	if states[KeyLabel(testKeyOne)] != KeyExhausted || states[KeyLabel(testKeyTwo)] != KeyHealthy {
+		t.Errorf("key states = %v, want first exhausted and second healthy", states)
+	}
+	if got := srv.Requests("playlists"); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+}
+
+func TestClientRevokesInvalidKey(t *testing.T) {
+	captureLog(t)
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv, testKeyOne, testKeyTwo)
+	srv.FailNext("playlists", http.StatusBadRequest, "keyInvalid")
+
+	if _, err := c.GetPlaylist(context.Background(), testPlaylist); err != nil {
+		t.Fatalf("GetPlaylist: %v", err)
+	}
+	if states := keyStates(c); states[KeyLabel(testKeyOne)] != KeyRevoked {
+		t.Errorf("key states = %v, want first revoked", states)
+	}
+
+	// Berbeda dengan key yang kehabisan quota, key yang dicabut tidak pulih
+	// di hari quota berikutnya.
+	for _, day := range []string{"2024-01-01", "2024-01-02"} {
+		k, err := c.keys.acquire(day)
+		if err != nil {
+			t.Fatalf("acquire(%s): %v", day, err)
+		}
+		if k.value != testKeyTwo {
+			t.Errorf("acquire(%s) = %s, want the second key", day, k.label)
+		}
+	}
+}
+
+func TestClientAllKeysExhausted(t *testing.T) {
+	captureLog(t)
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv, testKeyOne, testKeyTwo)
+	srv.FailNext("playlists", http.StatusForbidden, "quotaExceeded")
+	srv.FailNext("playlists", http.StatusForbidden, "quotaExceeded")
+
+	_, err := c.GetPlaylist(context.Background(), testPlaylist)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("GetPlaylist error = %v, want %v", err, ErrQuotaExceeded)
+	}
+	if got := srv.Requests("playlists"); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+
+	// Request berikutnya langsung gagal tanpa menghubungi YouTube.
+	if _, err := c.GetPlaylist(context.Background(), testPlaylist); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("second GetPlaylist error = %v, want %v", err, ErrQuotaExceeded)
+	}
+	if got := srv.Requests("playlists"); got != 2 {
+		t.Errorf("requests after exhaustion = %d, want 2", got)
+	}
+}
+
+func TestClientNeverLeaksKeys(t *testing.T) {
+	logs := captureLog(t)
+	srv := youtubetest.NewServer()
+	defer srv.Close()
+	c := newTestClient(srv, testKeyOne, testKeyTwo)
+	srv.FailNext("playlists", http.StatusBadRequest, "keyInvalid")
+	srv.FailNext("playlists", http.StatusForbidden, "quotaExceeded")
+
+	_, err := c.GetPlaylist(context.Background(), testPlaylist)
+	if err == nil {
+		t.Fatal("GetPlaylist succeeded, want every key rejected")
+	}
+	texts := []string{err.Error(), logs.String()}
+	for _, st := range c.KeyStatuses() {
+		texts = append(texts, st.Label, st.LastError)
+	}
+	for _, text := range texts {
+		for _, key := range []string{testKeyOne, testKeyTwo} {
+			if strings.Contains(text, key) {
+				t.Errorf("%q contains the raw API key", text)
+			}
+		}
+	}
+	if !strings.Contains(logs.String(), KeyLabel(testKeyOne)) {
+		t.Errorf("log = %q, want the label of the rejected key", logs.String())
+	}
+}
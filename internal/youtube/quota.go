@@ -0,0 +1,223 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+	_ "time/tzdata" // Image alpine tidak membawa zoneinfo untuk America/Los_Angeles
+)
+
+// DefaultDailyBudget adalah quota harian bawaan satu project YouTube Data API.
+const DefaultDailyBudget = 10000
+
+// endpointCost adalah biaya unit quota per panggilan untuk setiap endpoint.
+// Lihat https://developers.google.com/youtube/v3/determine_quota_cost
+var endpointCost = map[string]int{
+	"channels":      1,
+	"playlists":     1,
+	"playlistItems": 1,
+	"videos":        1,
+	"search":        100,
+}
+
+// ErrBudgetExhausted dikembalikan sebelum request dikirim jika budget harian sudah terpakai habis.
+var ErrBudgetExhausted = errors.New("youtube: daily quota budget exhausted")
+
+// quotaLocation adalah zona waktu tempat quota YouTube di-reset (tengah malam Pacific Time).
+var quotaLocation = loadQuotaLocation()
+
+func loadQuotaLocation() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+// QuotaDay mengembalikan hari quota (format YYYY-MM-DD) untuk waktu t.
+func QuotaDay(t time.Time) string {
+	return t.In(quotaLocation).Format("2006-01-02")
+}
+
+// QuotaStore menyimpan pemakaian quota per hari secara persisten dan dipakai
+// bersama semua proses worker.
+type QuotaStore interface {
+	// ReserveQuota mencatat units hanya jika total pemakaian day tidak melewati
+	// budget, secara atomik terhadap proses lain, dan mengembalikan total
+	// pemakaian day.
+	ReserveQuota(ctx context.Context, day string, endpoint string, keyLabel string, units int, budget int64) (used int64, reserved bool, err error)
+	GetQuotaUsage(ctx context.Context, day string) (map[string]int64, error)
+	GetQuotaUsageByKey(ctx context.Context, day string) (map[string]int64, error)
+}
+
+// QuotaUsage adalah ringkasan pemakaian quota untuk satu hari.
+type QuotaUsage struct {
+	Day        string           `json:"day"`
+	Budget     int64            `json:"budget"`
+	Used       int64            `json:"used"`
+	Remaining  int64            `json:"remaining"`
+	ByEndpoint map[string]int64 `json:"by_endpoint"`
+	ByKey      map[string]int64 `json:"by_key"`
+}
+
+// LoadQuotaUsage membaca pemakaian quota hari ini dari store.
+func LoadQuotaUsage(ctx context.Context, store QuotaStore, budget int64, now time.Time) (QuotaUsage, error) {
+	day := QuotaDay(now)
+	byEndpoint, err := store.GetQuotaUsage(ctx, day)
+	if err != nil {
+		return QuotaUsage{}, fmt.Errorf("failed to load quota usage: %w", err)
+	}
+	byKey, err := store.GetQuotaUsageByKey(ctx, day)
+	if err != nil {
+		return QuotaUsage{}, fmt.Errorf("failed to load quota usage: %w", err)
+	}
+	return newQuotaUsage(day, budget, byEndpoint, byKey), nil
+}
+
+func newQuotaUsage(day string, budget int64, byEndpoint, byKey map[string]int64) QuotaUsage {
+	usage := QuotaUsage{
+		Day:        day,
+		Budget:     budget,
+		ByEndpoint: make(map[string]int64, len(byEndpoint)),
+		ByKey:      make(map[string]int64, len(byKey)),
+	}
+	for endpoint, units := range byEndpoint {
+		usage.ByEndpoint[endpoint] = units
+		usage.Used += units
+	}
+	for label, units := range byKey {
+		usage.ByKey[label] = units
+	}
+	usage.Remaining = budget - usage.Used
+	if usage.Remaining < 0 {
+		usage.Remaining = 0
+	}
+	return usage
+}
+
+// QuotaTracker menghitung biaya unit setiap panggilan API dan menolak panggilan
+// baru setelah budget harian tercapai. Dengan store, budget dicek di store
+// sehingga berlaku bersama untuk semua replika dan proses worker; tanpa store,
+// pemakaian hanya dihitung di memori proses ini.
+type QuotaTracker struct {
+	store  QuotaStore
+	budget int64
+	now    func() time.Time
+
+	// Penghitung di memori, hanya dipakai jika store nil.
+	mu         sync.Mutex
+	day        string
+	byEndpoint map[string]int64
+	byKey      map[string]int64
+}
+
+// NewQuotaTracker membuat QuotaTracker dengan budget harian tertentu.
+// store boleh nil jika pemakaian tidak perlu disimpan.
+func NewQuotaTracker(store QuotaStore, budget int64) *QuotaTracker {
+	return &QuotaTracker{
+		store:      store,
+		budget:     budget,
+		byEndpoint: make(map[string]int64),
+		byKey:      make(map[string]int64),
+		now:        time.Now,
+	}
+}
+
+// cost mengembalikan biaya unit quota satu panggilan ke endpoint.
+func cost(endpoint string) int {
+	if c, ok := endpointCost[endpoint]; ok {
+		return c
+	}
+	return 1
+}
+
+// UnitCounter menghitung unit quota yang dipakai oleh request dengan context
+// tertentu, misalnya untuk mencatat biaya satu channel atau satu playlist.
+type UnitCounter struct {
+	units  atomic.Int64
+	parent *UnitCounter
+}
+
+type unitCounterKey struct{}
+
+// WithUnitCounter mengembalikan context turunan ctx beserta UnitCounter baru.
+// Setiap request Client yang memakai context tersebut dihitung ke counter ini
+// dan ke semua counter milik context induknya.
+func WithUnitCounter(ctx context.Context) (context.Context, *UnitCounter) {
+	parent, _ := ctx.Value(unitCounterKey{}).(*UnitCounter)
+	c := &UnitCounter{parent: parent}
+	return context.WithValue(ctx, unitCounterKey{}, c), c
+}
+
+// Units mengembalikan jumlah unit yang sudah dihitung.
+func (c *UnitCounter) Units() int64 {
+	return c.units.Load()
+}
+
+// countUnits menambahkan units ke semua UnitCounter di ctx.
+func countUnits(ctx context.Context, units int) {
+	c, _ := ctx.Value(unitCounterKey{}).(*UnitCounter)
+	for ; c != nil; c = c.parent {
+		c.units.Add(int64(units))
+	}
+}
+
+// reserve mencatat biaya satu panggilan ke endpoint dengan key keyLabel, atau
+// mengembalikan ErrBudgetExhausted jika panggilan itu akan melewati budget.
+func (t *QuotaTracker) reserve(ctx context.Context, endpoint string, keyLabel string) error {
+	cost := cost(endpoint)
+	day := QuotaDay(t.now())
+
+	if t.store != nil {
+		used, reserved, err := t.store.ReserveQuota(ctx, day, endpoint, keyLabel, cost, t.budget)
+		if err != nil {
+			return fmt.Errorf("failed to record quota usage: %w", err)
+		}
+		if !reserved {
+			return fmt.Errorf("%w (%d of %d units used)", ErrBudgetExhausted, used, t.budget)
+		}
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(day)
+
+	var used int64
+	for _, units := range t.byEndpoint {
+		used += units
+	}
+	if used+int64(cost) > t.budget {
+		return fmt.Errorf("%w (%d of %d units used)", ErrBudgetExhausted, used, t.budget)
+	}
+	t.byEndpoint[endpoint] += int64(cost)
+	t.byKey[keyLabel] += int64(cost)
+	return nil
+}
+
+// rollover mengosongkan penghitung di memori saat hari quota berganti. Harus
+// dipanggil dengan mu terkunci.
+func (t *QuotaTracker) rollover(day string) {
+	if day != t.day {
+		t.day = day
+		t.byEndpoint = make(map[string]int64)
+		t.byKey = make(map[string]int64)
+	}
+}
+
+// Usage mengembalikan ringkasan pemakaian quota hari ini. Dengan store,
+// pemakaian dibaca ulang dari store sehingga ikut menghitung proses lain, dan
+// error dikembalikan jika store tidak bisa dibaca.
+func (t *QuotaTracker) Usage(ctx context.Context) (QuotaUsage, error) {
+	if t.store != nil {
+		return LoadQuotaUsage(ctx, t.store, t.budget, t.now())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(QuotaDay(t.now()))
+	return newQuotaUsage(t.day, t.budget, t.byEndpoint, t.byKey), nil
+}
@@ -0,0 +1,53 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// failingQuotaStore menolak setiap pembacaan pemakaian quota.
+type failingQuotaStore struct{ err error }
+
+func (s failingQuotaStore) ReserveQuota(ctx context.Context, day string, endpoint string, keyLabel string, units int, budget int64) (int64, bool, error) {
+	return 0, false, s.err
+}
+
+func (s failingQuotaStore) GetQuotaUsage(ctx context.Context, day string) (map[string]int64, error) {
+	return nil, s.err
+}
+
+func (s failingQuotaStore) GetQuotaUsageByKey(ctx context.Context, day string) (map[string]int64, error) {
+	return nil, s.err
+}
+
+func TestQuotaTrackerUsageReturnsStoreError(t *testing.T) {
+	errDown := errors.New("database is down")
+	tracker := NewQuotaTracker(failingQuotaStore{err: errDown}, 100)
+	if _, err := tracker.Usage(context.Background()); !errors.Is(err, errDown) {
+		t.Errorf("Usage() error = %v, want %v", err, errDown)
+	}
+	if err := tracker.reserve(context.Background(), "videos", "key-1"); !errors.Is(err, errDown) {
+		t.Errorf("reserve() error = %v, want %v", err, errDown)
+	}
+}
+
+func TestQuotaTrackerInMemoryBudget(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewQuotaTracker(nil, 2)
+	for i := range 2 {
+		if err := tracker.reserve(ctx, "videos", "key-1"); err != nil {
+			t.Fatalf("reserve %d: %v", i, err)
+		}
+	}
+	if err := tracker.reserve(ctx, "videos", "key-1"); !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("reserve over budget: error = %v, want %v", err, ErrBudgetExhausted)
+	}
+	usage, err := tracker.Usage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Used != 2 || usage.Remaining != 0 || usage.ByKey["key-1"] != 2 {
+		t.Errorf("usage = %+v, want 2 used, 0 remaining, 2 by key-1", usage)
+	}
+}
@@ -0,0 +1,83 @@
+package youtube
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit membatasi laju request ke API menjadi perSecond request per
+// detik dengan lonjakan hingga burst request. Batas ini berlaku untuk semua
+// goroutine yang memakai Client yang sama, termasuk retry.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(perSecond, burst)
+	}
+}
+
+// rateLimiter adalah token bucket sederhana.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration // Waktu pengisian satu token
+	burst    float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		interval: time.Duration(float64(time.Second) / perSecond),
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// reserve mengambil satu token dan mengembalikan lama menunggu sampai token
+// itu tersedia (0 jika sudah tersedia). Token diambil sekarang walau belum tersedia, sehingga
+// goroutine yang menunggu dilayani berurutan.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	return max(0, time.Duration(-l.tokens*float64(l.interval)))
+}
+
+// cancel mengembalikan token yang diambil reserve saat request batal dikirim.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	l.tokens++
+	l.mu.Unlock()
+}
+
+// wait memblokir sampai satu request boleh dikirim, atau sampai ctx selesai.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,77 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	// 10 request per detik: satu token terisi setiap 100ms.
+	type step struct {
+		advance time.Duration
+		want    time.Duration
+	}
+	tests := []struct {
+		name  string
+		burst int
+		steps []step
+	}{
+		{
+			name:  "burst is served immediately, then requests queue",
+			burst: 3,
+			steps: []step{{0, 0}, {0, 0}, {0, 0}, {0, 100 * time.Millisecond}, {0, 200 * time.Millisecond}},
+		},
+		{
+			name:  "tokens refill with elapsed time",
+			burst: 3,
+			steps: []step{{0, 0}, {0, 0}, {0, 0}, {250 * time.Millisecond, 0}, {0, 0}, {0, 50 * time.Millisecond}},
+		},
+		{
+			name:  "idle time never refills beyond burst",
+			burst: 2,
+			steps: []step{{time.Minute, 0}, {0, 0}, {0, 100 * time.Millisecond}},
+		},
+		{
+			name:  "burst below one is treated as one",
+			burst: 0,
+			steps: []step{{0, 0}, {0, 100 * time.Millisecond}, {100 * time.Millisecond, 100 * time.Millisecond}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := time.Date(2025, 8, 7, 0, 0, 0, 0, time.UTC)
+			l := newRateLimiter(10, tt.burst)
+			l.now = func() time.Time { return clock }
+			l.last = clock
+
+			for i, s := range tt.steps {
+				clock = clock.Add(s.advance)
+				if got := l.reserve(); got != s.want {
+					t.Errorf("step %d: reserve() = %s, want %s", i, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterWaitCancelReturnsToken(t *testing.T) {
+	clock := time.Date(2025, 8, 7, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(10, 1)
+	l.now = func() time.Time { return clock }
+	l.last = clock
+
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("wait with cancelled context = %v, want context.Canceled", err)
+	}
+	// Token yang batal dipakai dikembalikan, jadi antrean tidak bertambah panjang.
+	if got, want := l.reserve(), 100*time.Millisecond; got != want {
+		t.Errorf("reserve() after cancelled wait = %s, want %s", got, want)
+	}
+}
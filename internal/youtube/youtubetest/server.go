@@ -0,0 +1,425 @@
+// Package youtubetest menyediakan server YouTube Data API v3 palsu berbasis
+// httptest yang melayani data dari file fixture, sehingga youtube.Client dan
+// pipeline ingest bisa dijalankan tanpa koneksi ke googleapis.com.
+package youtubetest
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"embed"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed fixtures/*.json
+var defaultFixtures embed.FS
+
+const (
+	defaultMaxResults = 5
+	maxMaxResults     = 50
+)
+
+// Server adalah server YouTube Data API palsu. Gunakan Server.URL sebagai
+// base URL youtube.Client (lihat youtube.WithBaseURL).
+type Server struct {
+	*httptest.Server
+
+	channels      []resource
+	playlists     []resource
+	playlistItems []resource
+	videos        []resource
+
+	mu          sync.Mutex
+	requests    map[string]int
+	notModified map[string]int
+	failures    map[string][]injectedError
+}
+
+// resource adalah satu item fixture beserta field yang dipakai untuk filter.
+type resource struct {
+	ID      string `json:"id"`
+	Snippet struct {
+		ChannelID  string `json:"channelId"`
+		PlaylistID string `json:"playlistId"`
+	} `json:"snippet"`
+	raw json.RawMessage
+}
+
+type injectedError struct {
+	status int
+	reason string
+}
+
+// Fixtures mengembalikan fixture bawaan paket ini, misalnya sebagai dasar
+// fixture yang dimodifikasi untuk NewServerFS.
+func Fixtures() fs.FS {
+	fixtures, err := fs.Sub(defaultFixtures, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return fixtures
+}
+
+// NewServer menjalankan server palsu dengan fixture bawaan paket ini.
+func NewServer() *Server {
+	s, err := NewServerFS(Fixtures())
+	if err != nil {
+		panic(fmt.Sprintf("youtubetest: invalid built-in fixtures: %v", err))
+	}
+	return s
+}
+
+// NewServerFS menjalankan server palsu dengan fixture dari fsys. fsys harus
+// berisi channels.json, playlists.json, playlistItems.json dan videos.json,
+// masing-masing berupa array resource dengan format yang sama seperti API asli.
+func NewServerFS(fsys fs.FS) (*Server, error) {
+	s := &Server{
+		requests:    make(map[string]int),
+		notModified: make(map[string]int),
+		failures:    make(map[string][]injectedError),
+	}
+	if err := s.load(fsys); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/channels", s.handle("channels", s.listChannels))
+	mux.HandleFunc("/playlists", s.handle("playlists", s.listPlaylists))
+	mux.HandleFunc("/playlistItems", s.handle("playlistItems", s.listPlaylistItems))
+	mux.HandleFunc("/videos", s.handle("videos", s.listVideos))
+	s.Server = httptest.NewServer(mux)
+	return s, nil
+}
+
+// Reload mengganti fixture server yang sedang berjalan dengan isi fsys,
+// misalnya untuk mensimulasikan perubahan playlist di antara dua run. Jumlah
+// request tidak di-reset, dan ETag halaman yang isinya tidak berubah tetap sama.
+func (s *Server) Reload(fsys fs.FS) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(fsys)
+}
+
+func (s *Server) load(fsys fs.FS) error {
+	fixtures := make(map[string][]resource)
+	for _, name := range []string{"channels.json", "playlists.json", "playlistItems.json", "videos.json"} {
+		items, err := loadFixture(fsys, name)
+		if err != nil {
+			return err
+		}
+		fixtures[name] = items
+	}
+	s.channels = fixtures["channels.json"]
+	s.playlists = fixtures["playlists.json"]
+	s.playlistItems = fixtures["playlistItems.json"]
+	s.videos = fixtures["videos.json"]
+	return nil
+}
+
+func loadFixture(fsys fs.FS, name string) ([]resource, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture %s: %w", name, err)
+	}
+	items := make([]resource, 0, len(raws))
+	for _, raw := range raws {
+		var r resource
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, fmt.Errorf("failed to decode item in fixture %s: %w", name, err)
+		}
+		// Item disimpan dalam bentuk kanonik (key terurut, tanpa spasi) agar ETag
+		// hanya bergantung pada isi, bukan format file fixture.
+		var content interface{}
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err := dec.Decode(&content); err != nil {
+			return nil, fmt.Errorf("failed to decode item in fixture %s: %w", name, err)
+		}
+		if r.raw, err = json.Marshal(content); err != nil {
+			return nil, fmt.Errorf("failed to encode item in fixture %s: %w", name, err)
+		}
+		items = append(items, r)
+	}
+	return items, nil
+}
+
+// FailNext membuat request berikutnya ke endpoint gagal dengan status dan
+// reason tertentu (misalnya 403 "quotaExceeded" atau 503 "backendError").
+// Beberapa panggilan akan diantrekan berurutan.
+func (s *Server) FailNext(endpoint string, status int, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[endpoint] = append(s.failures[endpoint], injectedError{status: status, reason: reason})
+}
+
+// Requests mengembalikan jumlah request yang diterima untuk endpoint.
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// NotModified mengembalikan jumlah request ke endpoint yang dijawab 304 Not Modified.
+func (s *Server) NotModified(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.notModified[endpoint]
+}
+
+// listHandler memilih item untuk satu request. paged bernilai false untuk
+// lookup berdasarkan id, yang di API asli tidak dipaginasi.
+type listHandler func(w http.ResponseWriter, r *http.Request) (kind string, items []resource, paged bool, ok bool)
+
+func (s *Server) handle(endpoint string, list listHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[endpoint]++
+		var injected *injectedError
+		if queue := s.failures[endpoint]; len(queue) > 0 {
+			injected = &queue[0]
+			s.failures[endpoint] = queue[1:]
+		}
+		s.mu.Unlock()
+
+		if injected != nil {
+			writeError(w, injected.status, injected.reason, "injected failure")
+			return
+		}
+		if r.Header.Get("X-Goog-Api-Key") == "" && r.URL.Query().Get("key") == "" {
+			writeError(w, http.StatusForbidden, "forbidden", "The request is missing a valid API key.")
+			return
+		}
+
+		// Fixture bisa diganti lewat Reload saat server berjalan.
+		s.mu.Lock()
+		kind, items, paged, ok := list(w, r)
+		s.mu.Unlock()
+		if !ok {
+			return
+		}
+		var notModified bool
+		if paged {
+			notModified = writePage(w, r, kind, items)
+		} else {
+			notModified = writeItems(w, r, kind, items)
+		}
+		if notModified {
+			s.mu.Lock()
+			s.notModified[endpoint]++
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Server) listChannels(w http.ResponseWriter, r *http.Request) (string, []resource, bool, bool) {
+	ids := splitIDs(r.URL.Query().Get("id"))
+	return "youtube#channelListResponse", filterByID(s.channels, ids), false, true
+}
+
+func (s *Server) listPlaylists(w http.ResponseWriter, r *http.Request) (string, []resource, bool, bool) {
+	q := r.URL.Query()
+	if ids := splitIDs(q.Get("id")); len(ids) > 0 {
+		return "youtube#playlistListResponse", filterByID(s.playlists, ids), false, true
+	}
+	channelID := q.Get("channelId")
+	var items []resource
+	for _, p := range s.playlists {
+		if p.Snippet.ChannelID == channelID {
+			items = append(items, p)
+		}
+	}
+	return "youtube#playlistListResponse", items, true, true
+}
+
+func (s *Server) listPlaylistItems(w http.ResponseWriter, r *http.Request) (string, []resource, bool, bool) {
+	playlistID := r.URL.Query().Get("playlistId")
+	if len(filterByID(s.playlists, []string{playlistID})) == 0 {
+		writeError(w, http.StatusNotFound, "playlistNotFound", "The playlist identified with the request's playlistId parameter cannot be found.")
+		return "", nil, false, false
+	}
+	var items []resource
+	for _, item := range s.playlistItems {
+		if item.Snippet.PlaylistID == playlistID {
+			items = append(items, item)
+		}
+	}
+	return "youtube#playlistItemListResponse", items, true, true
+}
+
+func (s *Server) listVideos(w http.ResponseWriter, r *http.Request) (string, []resource, bool, bool) {
+	ids := splitIDs(r.URL.Query().Get("id"))
+	if len(ids) > maxMaxResults {
+		writeError(w, http.StatusBadRequest, "badRequest", "Too many video IDs.")
+		return "", nil, false, false
+	}
+	return "youtube#videoListResponse", filterByID(s.videos, ids), false, true
+}
+
+// writePage menulis satu halaman hasil dengan nextPageToken/prevPageToken
+// seperti API asli dan melaporkan apakah jawabannya 304 Not Modified.
+func writePage(w http.ResponseWriter, r *http.Request, kind string, items []resource) bool {
+	q := r.URL.Query()
+	maxResults := defaultMaxResults
+	if v := q.Get("maxResults"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > maxMaxResults {
+			writeError(w, http.StatusBadRequest, "invalidParameter", "Invalid value for maxResults.")
+			return false
+		}
+		maxResults = n
+	}
+
+	offset := 0
+	if token := q.Get("pageToken"); token != "" {
+		var ok bool
+		offset, ok = decodePageToken(token)
+		if !ok || offset > len(items) {
+			writeError(w, http.StatusBadRequest, "invalidPageToken", "The request specifies an invalid page token.")
+			return false
+		}
+	}
+	end := offset + maxResults
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := listResponse{
+		Kind:     kind,
+		PageInfo: map[string]int{"totalResults": len(items), "resultsPerPage": maxResults},
+		Items:    []json.RawMessage{},
+	}
+	for _, item := range items[offset:end] {
+		page.Items = append(page.Items, item.raw)
+	}
+	if end < len(items) {
+		page.NextPageToken = encodePageToken(end)
+	}
+	if offset > 0 {
+		prev := offset - maxResults
+		if prev < 0 {
+			prev = 0
+		}
+		page.PrevPageToken = encodePageToken(prev)
+	}
+	return writeResponse(w, r, page)
+}
+
+// writeItems menulis semua item dalam satu respons tanpa pagination dan
+// melaporkan apakah jawabannya 304 Not Modified.
+func writeItems(w http.ResponseWriter, r *http.Request, kind string, items []resource) bool {
+	page := listResponse{
+		Kind:     kind,
+		PageInfo: map[string]int{"totalResults": len(items), "resultsPerPage": len(items)},
+		Items:    []json.RawMessage{},
+	}
+	for _, item := range items {
+		page.Items = append(page.Items, item.raw)
+	}
+	return writeResponse(w, r, page)
+}
+
+// listResponse adalah amplop respons list seperti API asli.
+type listResponse struct {
+	Kind          string            `json:"kind"`
+	ETag          string            `json:"etag"`
+	NextPageToken string            `json:"nextPageToken,omitempty"`
+	PrevPageToken string            `json:"prevPageToken,omitempty"`
+	PageInfo      map[string]int    `json:"pageInfo"`
+	Items         []json.RawMessage `json:"items"`
+}
+
+// writeResponse menulis page beserta ETag-nya, atau 304 Not Modified jika
+// If-None-Match pada request cocok dengan ETag tersebut. Mengembalikan true
+// untuk jawaban 304.
+func writeResponse(w http.ResponseWriter, r *http.Request, page listResponse) bool {
+	content, err := json.Marshal(page)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	sum := sha1.Sum(content)
+	page.ETag = base64.RawURLEncoding.EncodeToString(sum[:])
+	quoted := `"` + page.ETag + `"`
+
+	w.Header().Set("ETag", quoted)
+	if r.Header.Get("If-None-Match") == quoted {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(page)
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, reason, message string) {
+	body := map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+			"errors": []map[string]string{
+				{"message": message, "domain": "youtube.api", "reason": reason},
+			},
+		},
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// encodePageToken meniru format token YouTube: protobuf kecil berisi offset
+// (field 1) yang di-encode base64, misalnya offset 5 menjadi "CAUQAA".
+func encodePageToken(offset int) string {
+	buf := []byte{0x08}
+	buf = binary.AppendUvarint(buf, uint64(offset))
+	buf = append(buf, 0x10, 0x00)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodePageToken(token string) (int, bool) {
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(buf) < 2 || buf[0] != 0x08 {
+		return 0, false
+	}
+	offset, n := binary.Uvarint(buf[1:])
+	if n <= 0 {
+		return 0, false
+	}
+	return int(offset), true
+}
+
+func splitIDs(v string) []string {
+	var ids []string
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// filterByID mengembalikan resource dengan ID yang diminta, sesuai urutan ids.
+func filterByID(all []resource, ids []string) []resource {
+	byID := make(map[string]resource, len(all))
+	for _, r := range all {
+		byID[r.ID] = r
+	}
+	var items []resource
+	for _, id := range ids {
+		if r, ok := byID[id]; ok {
+			items = append(items, r)
+		}
+	}
+	return items
+}